@@ -1,14 +1,12 @@
 package ansible
 
 import (
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
-	"github.com/rs/zerolog/log"
 	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
 )
 
@@ -20,41 +18,50 @@ const (
 	ansiblePlaybookBin = "/usr/local/bin/ansible-playbook"
 )
 
-var ErrAnsiblePlaybookNotFound = errors.New("no playbook found")
-
 type Ansible struct {
-	GalaxyRequirements string
-	Inventories        []string
-	Playbooks          []string
-	Limit              string
-	SkipTags           string
-	StartAtTask        string
-	Tags               string
-	ExtraVars          []string
-	ModulePath         []string
-	Check              bool
-	Diff               bool
-	FlushCache         bool
-	ForceHandlers      bool
-	ListHosts          bool
-	ListTags           bool
-	ListTasks          bool
-	SyntaxCheck        bool
-	Forks              int
-	VaultID            string
-	VaultPasswordFile  string
-	Verbose            int
-	PrivateKeyFile     string
-	User               string
-	Connection         string
-	Timeout            int
-	SSHCommonArgs      string
-	SFTPExtraArgs      string
-	SCPExtraArgs       string
-	SSHExtraArgs       string
-	Become             bool
-	BecomeMethod       string
-	BecomeUser         string
+	BinDir                       string
+	Python                       string
+	VersionInfo                  *VersionInfo
+	ConfigFile                   string
+	GalaxyRequirements           string
+	GalaxyCollectionRequirements string
+	GalaxyRolesPath              string
+	GalaxyCollectionsPath        string
+	ConfigRolesPath              []string
+	GalaxyOffline                bool
+	GalaxyVendorPath             string
+	Inventories                  []string
+	Playbooks                    []string
+	PlaybookExcludes             []string
+	Limit                        string
+	SkipTags                     string
+	StartAtTask                  string
+	Tags                         string
+	ExtraVars                    []string
+	ModulePath                   []string
+	Check                        bool
+	Diff                         bool
+	FlushCache                   bool
+	ForceHandlers                bool
+	ListHosts                    bool
+	ListTags                     bool
+	ListTasks                    bool
+	SyntaxCheck                  bool
+	Forks                        int
+	VaultID                      string
+	VaultPasswordFile            string
+	Verbose                      int
+	PrivateKeyFile               string
+	User                         string
+	Connection                   string
+	Timeout                      int
+	SSHCommonArgs                string
+	SFTPExtraArgs                string
+	SCPExtraArgs                 string
+	SSHExtraArgs                 string
+	Become                       bool
+	BecomeMethod                 string
+	BecomeUser                   string
 }
 
 // Version runs the Ansible binary with the --version flag to retrieve the current version.
@@ -63,57 +70,49 @@ func (a *Ansible) Version() *plugin_exec.Cmd {
 		"--version",
 	}
 
-	cmd := plugin_exec.Command(ansibleBin, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd
+	return a.command(ansibleBin, args...)
 }
 
-// GetPlaybooks retrieves the list of Ansible playbook files based on the configured playbook patterns.
-func (a *Ansible) GetPlaybooks() error {
-	var playbooks []string
-
-	for _, pb := range a.Playbooks {
-		files, err := filepath.Glob(pb)
-		if err != nil {
-			playbooks = append(playbooks, pb)
+// Environ returns the environment variables required by ansible to find
+// roles and collections installed to custom paths. The ConfigRolesPath is
+// appended to keep the roles path of the ansible config, which is overridden
+// by the environment variable.
+func (a *Ansible) Environ() []string {
+	env := make([]string, 0)
 
-			continue
-		}
+	rolesPath := make([]string, 0)
 
-		playbooks = append(playbooks, files...)
+	if a.GalaxyOffline && a.GalaxyVendorPath != "" {
+		rolesPath = append(rolesPath, a.GalaxyVendorPath)
 	}
 
-	if len(playbooks) == 0 {
-		log.Debug().Strs("patterns", a.Playbooks).Msg("no playbooks found")
-
-		return ErrAnsiblePlaybookNotFound
+	if a.GalaxyRolesPath != "" {
+		rolesPath = append(rolesPath, a.GalaxyRolesPath)
 	}
 
-	a.Playbooks = append(a.Playbooks, playbooks...)
-
-	return nil
-}
+	if len(rolesPath) > 0 {
+		rolesPath = append(rolesPath, a.ConfigRolesPath...)
+	}
 
-// GalaxyInstall runs the ansible-galaxy install command with the configured options.
-func (a *Ansible) GalaxyInstall() *plugin_exec.Cmd {
-	args := []string{
-		"install",
-		"--force",
-		"--role-file",
-		a.GalaxyRequirements,
+	if a.ConfigFile != "" {
+		env = append(env, fmt.Sprintf("ANSIBLE_CONFIG=%s", a.ConfigFile))
 	}
 
-	if a.Verbose > 0 {
-		args = append(args, fmt.Sprintf("-%s", strings.Repeat("v", a.Verbose)))
+	if len(rolesPath) > 0 {
+		env = append(env, fmt.Sprintf("ANSIBLE_ROLES_PATH=%s", strings.Join(rolesPath, ":")))
 	}
 
-	cmd := plugin_exec.Command(ansibleGalaxyBin, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if a.GalaxyCollectionsPath != "" {
+		// Ansible before 2.10 only reads the plural form of the variable.
+		name := "ANSIBLE_COLLECTIONS_PATH"
+		if !a.Supports(FeatureCollectionsPath) {
+			name = "ANSIBLE_COLLECTIONS_PATHS"
+		}
 
-	return cmd
+		env = append(env, fmt.Sprintf("%s=%s", name, a.GalaxyCollectionsPath))
+	}
+
+	return env
 }
 
 // Play runs the Ansible playbook with the configured options.
@@ -146,22 +145,14 @@ func (a *Ansible) Play() *plugin_exec.Cmd {
 		args = append(args, "--list-hosts")
 		args = append(args, a.Playbooks...)
 
-		cmd := plugin_exec.Command(ansiblePlaybookBin, args...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		return cmd
+		return a.command(ansiblePlaybookBin, args...)
 	}
 
 	if a.SyntaxCheck {
 		args = append(args, "--syntax-check")
 		args = append(args, a.Playbooks...)
 
-		cmd := plugin_exec.Command(ansiblePlaybookBin, args...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-
-		return cmd
+		return a.command(ansiblePlaybookBin, args...)
 	}
 
 	if a.Check {
@@ -258,7 +249,22 @@ func (a *Ansible) Play() *plugin_exec.Cmd {
 
 	args = append(args, a.Playbooks...)
 
-	cmd := plugin_exec.Command(ansiblePlaybookBin, args...)
+	return a.command(ansiblePlaybookBin, args...)
+}
+
+// command returns the command for the given ansible binary. The binary is looked up
+// in the configured binary path and run with the configured Python interpreter.
+func (a *Ansible) command(bin string, args ...string) *plugin_exec.Cmd {
+	if a.BinDir != "" {
+		bin = filepath.Join(a.BinDir, filepath.Base(bin))
+	}
+
+	if a.Python != "" {
+		args = append([]string{bin}, args...)
+		bin = a.Python
+	}
+
+	cmd := plugin_exec.Command(bin, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
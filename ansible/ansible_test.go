@@ -17,50 +17,25 @@ func TestVersion(t *testing.T) {
 			ansible: &Ansible{},
 			want:    []string{ansibleBin, "--version"},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			cmd := tt.ansible.Version()
-			assert.Equal(t, tt.want, cmd.Args)
-		})
-	}
-}
-
-func TestGalaxyInstall(t *testing.T) {
-	tests := []struct {
-		name    string
-		ansible *Ansible
-		want    []string
-	}{
 		{
-			name: "with valid requirements file and no verbosity",
+			name: "test version command with binary path",
 			ansible: &Ansible{
-				GalaxyRequirements: "requirements.yml",
+				BinDir: "/tmp/venv/bin",
 			},
-			want: []string{ansibleGalaxyBin, "install", "--force", "--role-file", "requirements.yml"},
+			want: []string{"/tmp/venv/bin/ansible", "--version"},
 		},
 		{
-			name: "with valid requirements file and verbosity level 1",
+			name: "test version command with python interpreter",
 			ansible: &Ansible{
-				GalaxyRequirements: "requirements.yml",
-				Verbose:            1,
+				Python: "/tmp/venv/bin/python3",
 			},
-			want: []string{ansibleGalaxyBin, "install", "--force", "--role-file", "requirements.yml", "-v"},
-		},
-		{
-			name: "with valid requirements file and verbosity level 3",
-			ansible: &Ansible{
-				GalaxyRequirements: "requirements.yml",
-				Verbose:            3,
-			},
-			want: []string{ansibleGalaxyBin, "install", "--force", "--role-file", "requirements.yml", "-vvv"},
+			want: []string{"/tmp/venv/bin/python3", ansibleBin, "--version"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd := tt.ansible.GalaxyInstall()
+			cmd := tt.ansible.Version()
 			assert.Equal(t, tt.want, cmd.Args)
 		})
 	}
@@ -0,0 +1,231 @@
+package ansible
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrConfigInvalid = errors.New("invalid ansible config")
+
+// configPaths are the options ansible resolves relative to the directory of the config
+// file, mapped to the separator of their values. Options holding a single path have no
+// separator.
+//
+//nolint:gochecknoglobals
+var configPaths = map[string]map[string]string{
+	"defaults": {
+		"action_plugins":       ":",
+		"become_plugins":       ":",
+		"cache_plugins":        ":",
+		"callback_plugins":     ":",
+		"cliconf_plugins":      ":",
+		"collections_path":     ":",
+		"collections_paths":    ":",
+		"connection_plugins":   ":",
+		"doc_fragment_plugins": ":",
+		"filter_plugins":       ":",
+		"httpapi_plugins":      ":",
+		"inventory":            ",",
+		"inventory_plugins":    ":",
+		"library":              ":",
+		"log_path":             "",
+		"lookup_plugins":       ":",
+		"module_utils":         ":",
+		"netconf_plugins":      ":",
+		"playbook_dir":         "",
+		"private_key_file":     "",
+		"roles_path":           ":",
+		"strategy_plugins":     ":",
+		"terminal_plugins":     ":",
+		"test_plugins":         ":",
+		"vars_plugins":         ":",
+		"vault_password_file":  "",
+	},
+	"galaxy": {
+		"cache_dir": "",
+	},
+}
+
+// Config represents the sections and options of an ansible.cfg file.
+type Config struct {
+	Sections []*ConfigSection
+}
+
+// ConfigSection is a named section of an ansible.cfg file.
+type ConfigSection struct {
+	Name    string
+	Options []*ConfigOption
+}
+
+// ConfigOption is a single key value pair of a config section.
+type ConfigOption struct {
+	Key   string
+	Value string
+}
+
+// ReadConfig parses the given ansible.cfg file. Comments are not preserved.
+func ReadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseConfig(f)
+}
+
+// ParseConfig parses an ansible.cfg in INI format.
+func ParseConfig(r io.Reader) (*Config, error) {
+	cfg := &Config{}
+	scanner := bufio.NewScanner(r)
+
+	var (
+		section *ConfigSection
+		option  *ConfigOption
+		lineNum int
+	)
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			continue
+		case option != nil && (line[0] == ' ' || line[0] == '\t'):
+			// Indented lines continue the value of the previous option.
+			option.Value = fmt.Sprintf("%s\n%s", option.Value, trimmed)
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section = cfg.section(strings.TrimSpace(trimmed[1 : len(trimmed)-1]))
+			option = nil
+		default:
+			if section == nil {
+				return nil, fmt.Errorf("%w: line %d: option outside of section", ErrConfigInvalid, lineNum)
+			}
+
+			i := strings.IndexAny(trimmed, "=:")
+			if i < 0 {
+				return nil, fmt.Errorf("%w: line %d: missing value delimiter", ErrConfigInvalid, lineNum)
+			}
+
+			value := strings.TrimSpace(trimmed[i+1:])
+			if j := strings.Index(value, " ;"); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+
+			option = section.set(strings.TrimSpace(trimmed[:i]), value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Set sets the value of an option, adding the section and option if required.
+func (c *Config) Set(section, key, value string) {
+	c.section(section).set(key, value)
+}
+
+// Get returns the value of an option.
+func (c *Config) Get(section, key string) (string, bool) {
+	for _, s := range c.Sections {
+		if s.Name != section {
+			continue
+		}
+
+		for _, o := range s.Options {
+			if o.Key == key {
+				return o.Value, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// ResolvePaths makes the relative paths of path options absolute by joining them with
+// dir, as ansible would for a config file in dir. Paths using the home directory,
+// environment variables or templates are kept.
+func (c *Config) ResolvePaths(dir string) {
+	for _, s := range c.Sections {
+		for _, o := range s.Options {
+			sep, ok := configPaths[s.Name][o.Key]
+			if !ok {
+				continue
+			}
+
+			paths := []string{o.Value}
+			if sep != "" {
+				paths = strings.Split(o.Value, sep)
+			}
+
+			for i, path := range paths {
+				path = strings.TrimSpace(path)
+				if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "~") ||
+					strings.HasPrefix(path, "$") || strings.HasPrefix(path, "{{") {
+					continue
+				}
+
+				paths[i] = filepath.Join(dir, path)
+			}
+
+			o.Value = strings.Join(paths, sep)
+		}
+	}
+}
+
+// String renders the config in INI format.
+func (c *Config) String() string {
+	var b strings.Builder
+
+	for i, s := range c.Sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(&b, "[%s]\n", s.Name)
+
+		for _, o := range s.Options {
+			fmt.Fprintf(&b, "%s = %s\n", o.Key, strings.ReplaceAll(o.Value, "\n", "\n    "))
+		}
+	}
+
+	return b.String()
+}
+
+func (c *Config) section(name string) *ConfigSection {
+	for _, s := range c.Sections {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	s := &ConfigSection{Name: name}
+	c.Sections = append(c.Sections, s)
+
+	return s
+}
+
+func (s *ConfigSection) set(key, value string) *ConfigOption {
+	for _, o := range s.Options {
+		if o.Key == key {
+			o.Value = value
+
+			return o
+		}
+	}
+
+	o := &ConfigOption{Key: key, Value: value}
+	s.Options = append(s.Options, o)
+
+	return o
+}
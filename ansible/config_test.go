@@ -0,0 +1,92 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "sections and comments",
+			input: `# comment
+[defaults]
+forks = 10 ; inline comment
+host_key_checking: False
+
+; comment
+[ssh_connection]
+pipelining=True
+`,
+			want: "[defaults]\nforks = 10\nhost_key_checking = False\n\n[ssh_connection]\npipelining = True\n",
+		},
+		{
+			name:  "continuation lines",
+			input: "[defaults]\ncallbacks_enabled = timer,\n    profile_tasks\n",
+			want:  "[defaults]\ncallbacks_enabled = timer,\n    profile_tasks\n",
+		},
+		{
+			name:    "option outside of section",
+			input:   "forks = 10\n",
+			wantErr: ErrConfigInvalid,
+		},
+		{
+			name:    "missing delimiter",
+			input:   "[defaults]\nforks\n",
+			wantErr: ErrConfigInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(strings.NewReader(tt.input))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.String())
+		})
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader("[defaults]\nforks = 5\nroles_path = roles\n"))
+	require.NoError(t, err)
+
+	cfg.Set("defaults", "forks", "20")
+	cfg.Set("ssh_connection", "pipelining", "True")
+
+	forks, ok := cfg.Get("defaults", "forks")
+	assert.True(t, ok)
+	assert.Equal(t, "20", forks)
+
+	_, ok = cfg.Get("defaults", "timeout")
+	assert.False(t, ok)
+
+	assert.Equal(t, "[defaults]\nforks = 20\nroles_path = roles\n\n[ssh_connection]\npipelining = True\n", cfg.String())
+}
+
+func TestConfigResolvePaths(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader("[defaults]\nforks = 5\nroles_path = roles:/usr/share/roles:~/roles\n" +
+		"inventory = inventories/prod.yml,hosts.yml\nvault_password_file = $VAULT_FILE\nlog_path = ansible.log\n\n" +
+		"[galaxy]\ncache_dir = .cache\n\n[ssh_connection]\ncontrol_path_dir = cp\n"))
+	require.NoError(t, err)
+
+	cfg.ResolvePaths("/build")
+
+	assert.Equal(t, "[defaults]\nforks = 5\nroles_path = /build/roles:/usr/share/roles:~/roles\n"+
+		"inventory = /build/inventories/prod.yml,/build/hosts.yml\nvault_password_file = $VAULT_FILE\n"+
+		"log_path = /build/ansible.log\n\n[galaxy]\ncache_dir = /build/.cache\n\n[ssh_connection]\ncontrol_path_dir = cp\n",
+		cfg.String())
+}
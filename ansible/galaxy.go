@@ -0,0 +1,310 @@
+package ansible
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrGalaxyRequirementsInvalid = errors.New("invalid galaxy requirements file")
+	ErrGalaxyOfflineMissing      = errors.New("galaxy requirements not found in vendor path")
+	ErrGalaxyOfflineUnsupported  = errors.New("galaxy requirement type not supported in offline mode")
+)
+
+// GalaxyRequirements holds the roles and collections defined in a galaxy requirements file.
+type GalaxyRequirements struct {
+	Roles       []GalaxyRequirement
+	Collections []GalaxyRequirement
+}
+
+// GalaxyRequirement represents a single role or collection entry of a galaxy requirements file.
+type GalaxyRequirement struct {
+	Name    string `yaml:"name"`
+	Src     string `yaml:"src"`
+	Source  string `yaml:"source"`
+	Type    string `yaml:"type"`
+	Version string `yaml:"version"`
+}
+
+// GalaxyPackage describes an installed role or collection.
+type GalaxyPackage struct {
+	Kind    string
+	Name    string
+	Version string
+	Path    string
+}
+
+// UnmarshalYAML supports both the short string form and the mapping form of a requirement.
+func (r *GalaxyRequirement) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.Name = node.Value
+
+		return nil
+	}
+
+	type plain GalaxyRequirement
+
+	return node.Decode((*plain)(r))
+}
+
+// UnmarshalYAML supports the legacy role list format as well as the
+// `roles` and `collections` mapping format.
+func (r *GalaxyRequirements) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return node.Decode(&r.Roles)
+	case yaml.MappingNode:
+		var v struct {
+			Roles       []GalaxyRequirement `yaml:"roles"`
+			Collections []GalaxyRequirement `yaml:"collections"`
+		}
+
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+
+		r.Roles = v.Roles
+		r.Collections = v.Collections
+
+		return nil
+	default:
+		return fmt.Errorf("%w: unexpected yaml node kind %d", ErrGalaxyRequirementsInvalid, node.Kind)
+	}
+}
+
+// RoleName returns the directory name ansible-galaxy uses to install the role.
+func (r GalaxyRequirement) RoleName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	src := r.Src
+	if !strings.Contains(src, "://") && !strings.HasPrefix(src, "git+") && !strings.Contains(src, "@") {
+		return src
+	}
+
+	if i := strings.Index(src, ","); i >= 0 {
+		src = src[:i]
+	}
+
+	name := src[strings.LastIndexAny(src, "/:")+1:]
+	for _, ext := range []string{".git", ".tar.gz", ".tar"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return name
+}
+
+// ReadGalaxyRequirements parses the given galaxy requirements file.
+func ReadGalaxyRequirements(path string) (*GalaxyRequirements, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &GalaxyRequirements{}
+
+	if err := yaml.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrGalaxyRequirementsInvalid, path, err)
+	}
+
+	return req, nil
+}
+
+// GalaxyInstall returns the ansible-galaxy commands required to install all roles and
+// collections defined in the configured requirements files.
+func (a *Ansible) GalaxyInstall() ([]*plugin_exec.Cmd, error) {
+	if a.GalaxyOffline {
+		return a.galaxyOfflineInstall()
+	}
+
+	cmds := make([]*plugin_exec.Cmd, 0)
+
+	for _, file := range a.GalaxyRequirementFiles() {
+		req, err := ReadGalaxyRequirements(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(req.Roles) > 0 {
+			cmds = append(cmds, a.GalaxyRoleInstall(file))
+		}
+
+		if len(req.Collections) > 0 {
+			cmds = append(cmds, a.GalaxyCollectionInstall(file))
+		}
+	}
+
+	return cmds, nil
+}
+
+// GalaxyRoleInstall runs the ansible-galaxy role install command for the given requirements file.
+func (a *Ansible) GalaxyRoleInstall(req string) *plugin_exec.Cmd {
+	args := []string{
+		"role",
+		"install",
+		"--force",
+		"--role-file",
+		req,
+	}
+
+	// Versions without subcommands install roles by default.
+	if !a.Supports(FeatureGalaxySubcommands) {
+		args = args[1:]
+	}
+
+	if a.GalaxyRolesPath != "" {
+		args = append(args, "--roles-path", a.GalaxyRolesPath)
+	}
+
+	if a.Verbose > 0 {
+		args = append(args, fmt.Sprintf("-%s", strings.Repeat("v", a.Verbose)))
+	}
+
+	return a.command(ansibleGalaxyBin, args...)
+}
+
+// GalaxyCollectionInstall runs the ansible-galaxy collection install command for the given requirements file.
+func (a *Ansible) GalaxyCollectionInstall(req string) *plugin_exec.Cmd {
+	args := []string{
+		"collection",
+		"install",
+		"--force",
+		"--requirements-file",
+		req,
+	}
+
+	if a.GalaxyCollectionsPath != "" {
+		args = append(args, "--collections-path", a.GalaxyCollectionsPath)
+	}
+
+	if a.Verbose > 0 {
+		args = append(args, fmt.Sprintf("-%s", strings.Repeat("v", a.Verbose)))
+	}
+
+	return a.command(ansibleGalaxyBin, args...)
+}
+
+// GalaxyInstalled returns the installed versions of all roles and collections
+// defined in the configured requirements files. Requirements that cannot be
+// found in the install paths are returned without a version.
+func (a *Ansible) GalaxyInstalled() ([]GalaxyPackage, error) {
+	pkgs := make([]GalaxyPackage, 0)
+
+	for _, file := range a.GalaxyRequirementFiles() {
+		req, err := ReadGalaxyRequirements(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, role := range req.Roles {
+			pkgs = append(pkgs, a.installedRole(role.RoleName()))
+		}
+
+		for _, collection := range req.Collections {
+			if collection.Type != "" && collection.Type != "galaxy" {
+				continue
+			}
+
+			pkgs = append(pkgs, a.installedCollection(collection.Name))
+		}
+	}
+
+	return pkgs, nil
+}
+
+// GalaxyRequirementFiles returns all configured galaxy requirements files.
+func (a *Ansible) GalaxyRequirementFiles() []string {
+	files := make([]string, 0)
+
+	for _, file := range []string{a.GalaxyRequirements, a.GalaxyCollectionRequirements} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+func (a *Ansible) installedRole(name string) GalaxyPackage {
+	pkg := GalaxyPackage{Kind: "role", Name: name}
+
+	rolesPath := a.GalaxyRolesPath
+
+	switch {
+	case a.GalaxyOffline:
+		rolesPath = a.GalaxyVendorPath
+	case rolesPath == "":
+		rolesPath = defaultGalaxyPath("roles")
+	}
+
+	pkg.Path = filepath.Join(rolesPath, name)
+
+	data, err := os.ReadFile(filepath.Join(pkg.Path, "meta", ".galaxy_install_info"))
+	if err != nil {
+		return pkg
+	}
+
+	var info struct {
+		Version string `yaml:"version"`
+	}
+
+	if err := yaml.Unmarshal(data, &info); err == nil {
+		pkg.Version = info.Version
+	}
+
+	return pkg
+}
+
+func (a *Ansible) installedCollection(name string) GalaxyPackage {
+	pkg := GalaxyPackage{Kind: "collection", Name: name}
+
+	namespace, collection, ok := strings.Cut(name, ".")
+	if !ok {
+		return pkg
+	}
+
+	collectionsPath := a.GalaxyCollectionsPath
+	if collectionsPath == "" {
+		collectionsPath = defaultGalaxyPath("collections")
+	}
+
+	if filepath.Base(collectionsPath) != "ansible_collections" {
+		collectionsPath = filepath.Join(collectionsPath, "ansible_collections")
+	}
+
+	pkg.Path = filepath.Join(collectionsPath, namespace, collection)
+
+	data, err := os.ReadFile(filepath.Join(pkg.Path, "MANIFEST.json"))
+	if err != nil {
+		return pkg
+	}
+
+	var manifest struct {
+		CollectionInfo struct {
+			Version string `json:"version"`
+		} `json:"collection_info"` //nolint:tagliatelle
+	}
+
+	if err := json.Unmarshal(data, &manifest); err == nil {
+		pkg.Version = manifest.CollectionInfo.Version
+	}
+
+	return pkg
+}
+
+func defaultGalaxyPath(kind string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".ansible", kind)
+}
@@ -0,0 +1,184 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
+)
+
+const collectionArtifactExt = ".tar.gz"
+
+type collectionArtifact struct {
+	Name    string
+	Version *semver.Version
+	Path    string
+}
+
+// GalaxyCollectionInstallArtifacts runs the ansible-galaxy collection install command
+// for the given collection artifacts without contacting any distribution server.
+func (a *Ansible) GalaxyCollectionInstallArtifacts(artifacts ...string) *plugin_exec.Cmd {
+	args := []string{
+		"collection",
+		"install",
+		"--offline",
+		"--force",
+	}
+
+	if a.GalaxyCollectionsPath != "" {
+		args = append(args, "--collections-path", a.GalaxyCollectionsPath)
+	}
+
+	if a.Verbose > 0 {
+		args = append(args, fmt.Sprintf("-%s", strings.Repeat("v", a.Verbose)))
+	}
+
+	args = append(args, artifacts...)
+
+	return a.command(ansibleGalaxyBin, args...)
+}
+
+// galaxyOfflineInstall resolves all requirements against the vendor path. Collections are
+// installed from the vendored artifacts, roles are used in place from the vendor path.
+func (a *Ansible) galaxyOfflineInstall() ([]*plugin_exec.Cmd, error) {
+	artifacts, err := readCollectionArtifacts(a.GalaxyVendorPath)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]string)
+	missing := make([]string, 0)
+
+	for _, file := range a.GalaxyRequirementFiles() {
+		req, err := ReadGalaxyRequirements(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, role := range req.Roles {
+			name := role.RoleName()
+
+			if info, err := os.Stat(filepath.Join(a.GalaxyVendorPath, name)); err != nil || !info.IsDir() {
+				missing = append(missing, fmt.Sprintf("role %s", name))
+			}
+		}
+
+		for _, collection := range req.Collections {
+			path, err := resolveCollectionArtifact(collection, artifacts)
+			if err != nil {
+				return nil, err
+			}
+
+			if path == "" {
+				missing = append(missing, fmt.Sprintf("collection %s %s", collection.Name, collection.Version))
+
+				continue
+			}
+
+			selected[collection.Name] = path
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s: %s", ErrGalaxyOfflineMissing, a.GalaxyVendorPath, strings.Join(missing, ", "))
+	}
+
+	if len(selected) == 0 {
+		return []*plugin_exec.Cmd{}, nil
+	}
+
+	// Vendored collections that are not required directly are most likely dependencies
+	// and need to be passed as well, as the resolver cannot look them up on a server.
+	for name, versions := range artifacts {
+		if _, ok := selected[name]; !ok {
+			selected[name] = versions[0].Path
+		}
+	}
+
+	paths := make([]string, 0, len(selected))
+	for _, path := range selected {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+
+	return []*plugin_exec.Cmd{a.GalaxyCollectionInstallArtifacts(paths...)}, nil
+}
+
+// resolveCollectionArtifact returns the path of the highest vendored artifact matching the
+// requirement. An empty path is returned if no matching artifact exists.
+func resolveCollectionArtifact(req GalaxyRequirement, artifacts map[string][]collectionArtifact) (string, error) {
+	switch req.Type {
+	case "", "galaxy":
+	case "file", "dir", "subdirs":
+		return req.Name, nil
+	default:
+		return "", fmt.Errorf("%w: %s (%s)", ErrGalaxyOfflineUnsupported, req.Name, req.Type)
+	}
+
+	version := strings.ReplaceAll(strings.TrimSpace(req.Version), "==", "=")
+	if version == "" {
+		version = "*"
+	}
+
+	constraint, err := semver.NewConstraint(version)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrGalaxyRequirementsInvalid, req.Name, err)
+	}
+
+	for _, artifact := range artifacts[req.Name] {
+		if constraint.Check(artifact.Version) {
+			return artifact.Path, nil
+		}
+	}
+
+	return "", nil
+}
+
+// readCollectionArtifacts returns all collection artifacts of the given directory
+// grouped by collection name and sorted by descending version.
+func readCollectionArtifacts(dir string) (map[string][]collectionArtifact, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make(map[string][]collectionArtifact)
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), collectionArtifactExt)
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		// Artifacts are named <namespace>-<name>-<version>, namespace and name must not contain dashes.
+		parts := strings.SplitN(name, "-", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		version, err := semver.NewVersion(parts[2])
+		if err != nil {
+			continue
+		}
+
+		fqcn := fmt.Sprintf("%s.%s", parts[0], parts[1])
+		artifacts[fqcn] = append(artifacts[fqcn], collectionArtifact{
+			Name:    fqcn,
+			Version: version,
+			Path:    filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	for _, versions := range artifacts {
+		slices.SortFunc(versions, func(a, b collectionArtifact) int {
+			return b.Version.Compare(a.Version)
+		})
+	}
+
+	return artifacts, nil
+}
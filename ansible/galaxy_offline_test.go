@@ -0,0 +1,161 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGalaxyOfflineInstall(t *testing.T) {
+	vendor := t.TempDir()
+
+	for _, artifact := range []string{
+		"community-general-8.6.0.tar.gz",
+		"community-general-9.1.0.tar.gz",
+		"ansible-posix-1.5.4.tar.gz",
+		"ansible-utils-4.1.0.tar.gz",
+		"README.md",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(vendor, artifact), nil, 0o600))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(vendor, "geerlingguy.docker"), 0o755))
+
+	tests := []struct {
+		name    string
+		content string
+		want    [][]string
+		wantErr error
+	}{
+		{
+			name:    "with vendored role only",
+			content: "roles:\n  - geerlingguy.docker\n",
+			want:    [][]string{},
+		},
+		{
+			name:    "with latest and pinned collections",
+			content: "collections:\n  - community.general\n  - name: ansible.posix\n    version: ==1.5.4\n",
+			want: [][]string{
+				{
+					ansibleGalaxyBin, "collection", "install", "--offline", "--force",
+					filepath.Join(vendor, "ansible-posix-1.5.4.tar.gz"),
+					filepath.Join(vendor, "ansible-utils-4.1.0.tar.gz"),
+					filepath.Join(vendor, "community-general-9.1.0.tar.gz"),
+				},
+			},
+		},
+		{
+			name:    "with version range",
+			content: "collections:\n  - name: community.general\n    version: \">=8.0.0,<9.0.0\"\n",
+			want: [][]string{
+				{
+					ansibleGalaxyBin, "collection", "install", "--offline", "--force",
+					filepath.Join(vendor, "ansible-posix-1.5.4.tar.gz"),
+					filepath.Join(vendor, "ansible-utils-4.1.0.tar.gz"),
+					filepath.Join(vendor, "community-general-8.6.0.tar.gz"),
+				},
+			},
+		},
+		{
+			name:    "with missing role and collection",
+			content: "roles:\n  - missing.role\ncollections:\n  - name: ansible.posix\n    version: 2.0.0\n",
+			wantErr: ErrGalaxyOfflineMissing,
+		},
+		{
+			name:    "with git collection",
+			content: "collections:\n  - name: https://github.com/org/collection.git\n    type: git\n",
+			wantErr: ErrGalaxyOfflineUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := filepath.Join(t.TempDir(), "requirements.yml")
+			require.NoError(t, os.WriteFile(req, []byte(tt.content), 0o600))
+
+			a := &Ansible{
+				GalaxyRequirements: req,
+				GalaxyOffline:      true,
+				GalaxyVendorPath:   vendor,
+			}
+
+			cmds, err := a.GalaxyInstall()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			got := make([][]string, 0)
+			for _, cmd := range cmds {
+				got = append(got, cmd.Args)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	tests := []struct {
+		name    string
+		ansible *Ansible
+		want    []string
+	}{
+		{
+			name:    "without custom paths",
+			ansible: &Ansible{},
+			want:    []string{},
+		},
+		{
+			name: "with custom paths",
+			ansible: &Ansible{
+				GalaxyRolesPath:       "/tmp/roles",
+				GalaxyCollectionsPath: "/tmp/collections",
+			},
+			want: []string{"ANSIBLE_ROLES_PATH=/tmp/roles", "ANSIBLE_COLLECTIONS_PATH=/tmp/collections"},
+		},
+		{
+			name: "with offline vendor path",
+			ansible: &Ansible{
+				GalaxyRolesPath:  "/tmp/roles",
+				GalaxyOffline:    true,
+				GalaxyVendorPath: "/vendor",
+			},
+			want: []string{"ANSIBLE_ROLES_PATH=/vendor:/tmp/roles"},
+		},
+		{
+			name: "with config roles path",
+			ansible: &Ansible{
+				GalaxyRolesPath: "/tmp/roles",
+				ConfigRolesPath: []string{"/build/roles", "/build/vendor/roles"},
+			},
+			want: []string{"ANSIBLE_ROLES_PATH=/tmp/roles:/build/roles:/build/vendor/roles"},
+		},
+		{
+			name: "collections path with ansible before 2.10",
+			ansible: &Ansible{
+				GalaxyCollectionsPath: "/tmp/collections",
+				VersionInfo:           &VersionInfo{Core: semver.MustParse("2.9.27")},
+			},
+			want: []string{"ANSIBLE_COLLECTIONS_PATHS=/tmp/collections"},
+		},
+		{
+			name:    "config roles path only",
+			ansible: &Ansible{ConfigRolesPath: []string{"/build/roles"}},
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.ansible.Environ())
+		})
+	}
+}
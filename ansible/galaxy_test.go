@@ -0,0 +1,224 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadGalaxyRequirements(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *GalaxyRequirements
+		wantErr error
+	}{
+		{
+			name: "legacy role list",
+			content: "- src: geerlingguy.docker\n  version: 7.0.0\n" +
+				"- name: myrole\n  src: https://github.com/org/ansible-role-my.git\n",
+			want: &GalaxyRequirements{
+				Roles: []GalaxyRequirement{
+					{Src: "geerlingguy.docker", Version: "7.0.0"},
+					{Name: "myrole", Src: "https://github.com/org/ansible-role-my.git"},
+				},
+			},
+		},
+		{
+			name: "roles and collections",
+			content: "roles:\n  - name: geerlingguy.docker\ncollections:\n  - community.general\n" +
+				"  - name: ansible.posix\n    version: \">=1.5.0\"\n",
+			want: &GalaxyRequirements{
+				Roles: []GalaxyRequirement{{Name: "geerlingguy.docker"}},
+				Collections: []GalaxyRequirement{
+					{Name: "community.general"},
+					{Name: "ansible.posix", Version: ">=1.5.0"},
+				},
+			},
+		},
+		{
+			name:    "invalid format",
+			content: "roles",
+			wantErr: ErrGalaxyRequirementsInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "requirements.yml")
+			require.NoError(t, os.WriteFile(file, []byte(tt.content), 0o600))
+
+			got, err := ReadGalaxyRequirements(file)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRoleName(t *testing.T) {
+	tests := []struct {
+		name string
+		req  GalaxyRequirement
+		want string
+	}{
+		{
+			name: "galaxy role from src",
+			req:  GalaxyRequirement{Src: "geerlingguy.docker"},
+			want: "geerlingguy.docker",
+		},
+		{
+			name: "explicit name",
+			req:  GalaxyRequirement{Name: "docker", Src: "https://github.com/geerlingguy/ansible-role-docker.git"},
+			want: "docker",
+		},
+		{
+			name: "scm url",
+			req:  GalaxyRequirement{Src: "git+https://github.com/geerlingguy/ansible-role-docker.git"},
+			want: "ansible-role-docker",
+		},
+		{
+			name: "ssh url",
+			req:  GalaxyRequirement{Src: "git@github.com:geerlingguy/ansible-role-docker.git"},
+			want: "ansible-role-docker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.req.RoleName())
+		})
+	}
+}
+
+func TestGalaxyInstall(t *testing.T) {
+	dir := t.TempDir()
+	roles := filepath.Join(dir, "roles.yml")
+	mixed := filepath.Join(dir, "requirements.yml")
+	collections := filepath.Join(dir, "collections.yml")
+
+	require.NoError(t, os.WriteFile(roles, []byte("- src: geerlingguy.docker\n"), 0o600))
+	require.NoError(t, os.WriteFile(
+		mixed, []byte("roles:\n  - geerlingguy.docker\ncollections:\n  - community.general\n"), 0o600,
+	))
+	require.NoError(t, os.WriteFile(collections, []byte("collections:\n  - ansible.posix\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		ansible *Ansible
+		want    [][]string
+	}{
+		{
+			name:    "without requirements",
+			ansible: &Ansible{},
+			want:    [][]string{},
+		},
+		{
+			name: "with legacy roles file",
+			ansible: &Ansible{
+				GalaxyRequirements: roles,
+			},
+			want: [][]string{
+				{ansibleGalaxyBin, "role", "install", "--force", "--role-file", roles},
+			},
+		},
+		{
+			name: "with roles and collections",
+			ansible: &Ansible{
+				GalaxyRequirements: mixed,
+			},
+			want: [][]string{
+				{ansibleGalaxyBin, "role", "install", "--force", "--role-file", mixed},
+				{ansibleGalaxyBin, "collection", "install", "--force", "--requirements-file", mixed},
+			},
+		},
+		{
+			name: "with legacy ansible version",
+			ansible: &Ansible{
+				GalaxyRequirements: roles,
+				VersionInfo:        &VersionInfo{Core: semver.MustParse("2.8.20")},
+			},
+			want: [][]string{
+				{ansibleGalaxyBin, "install", "--force", "--role-file", roles},
+			},
+		},
+		{
+			name: "with separate collection requirements and custom paths",
+			ansible: &Ansible{
+				GalaxyRequirements:           roles,
+				GalaxyCollectionRequirements: collections,
+				GalaxyRolesPath:              "/tmp/roles",
+				GalaxyCollectionsPath:        "/tmp/collections",
+				Verbose:                      2,
+			},
+			want: [][]string{
+				{ansibleGalaxyBin, "role", "install", "--force", "--role-file", roles, "--roles-path", "/tmp/roles", "-vv"},
+				{
+					ansibleGalaxyBin, "collection", "install", "--force", "--requirements-file", collections,
+					"--collections-path", "/tmp/collections", "-vv",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmds, err := tt.ansible.GalaxyInstall()
+			require.NoError(t, err)
+
+			got := make([][]string, 0)
+			for _, cmd := range cmds {
+				got = append(got, cmd.Args)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGalaxyInstalled(t *testing.T) {
+	dir := t.TempDir()
+	req := filepath.Join(dir, "requirements.yml")
+	rolesPath := filepath.Join(dir, "roles")
+	collectionsPath := filepath.Join(dir, "collections")
+
+	require.NoError(t, os.WriteFile(req, []byte(
+		"roles:\n  - geerlingguy.docker\n  - missing.role\ncollections:\n  - community.general\n"+
+			"  - name: https://github.com/org/repo.git\n    type: git\n",
+	), 0o600))
+
+	roleMeta := filepath.Join(rolesPath, "geerlingguy.docker", "meta")
+	require.NoError(t, os.MkdirAll(roleMeta, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(roleMeta, ".galaxy_install_info"), []byte("version: 7.0.0\n"), 0o600))
+
+	collection := filepath.Join(collectionsPath, "ansible_collections", "community", "general")
+	require.NoError(t, os.MkdirAll(collection, 0o755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(collection, "MANIFEST.json"),
+		[]byte(`{"collection_info": {"namespace": "community", "name": "general", "version": "9.1.0"}}`), 0o600,
+	))
+
+	a := &Ansible{
+		GalaxyRequirements:    req,
+		GalaxyRolesPath:       rolesPath,
+		GalaxyCollectionsPath: collectionsPath,
+	}
+
+	got, err := a.GalaxyInstalled()
+	require.NoError(t, err)
+
+	assert.Equal(t, []GalaxyPackage{
+		{Kind: "role", Name: "geerlingguy.docker", Version: "7.0.0", Path: filepath.Join(rolesPath, "geerlingguy.docker")},
+		{Kind: "role", Name: "missing.role", Path: filepath.Join(rolesPath, "missing.role")},
+		{Kind: "collection", Name: "community.general", Version: "9.1.0", Path: collection},
+	}, got)
+}
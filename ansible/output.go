@@ -0,0 +1,83 @@
+package ansible
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+//nolint:gochecknoglobals
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// LineWriter is an io.Writer that passes each written line, including the line
+// break, to a handler.
+type LineWriter struct {
+	handler func(line string)
+	buf     []byte
+}
+
+// NewLineWriter creates a LineWriter calling the handler for each line.
+func NewLineWriter(handler func(line string)) *LineWriter {
+	return &LineWriter{handler: handler}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.handler(string(w.buf[:i+1]))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush passes a remaining incomplete line to the handler.
+func (w *LineWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+
+	w.handler(string(w.buf))
+	w.buf = nil
+}
+
+// StripANSI removes ANSI escape sequences like colors from the output.
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// NewDiffRedactWriter creates a LineWriter that writes the output to w with the
+// content of diffs replaced by a placeholder. Diff headers are preserved to show
+// which files would be changed.
+func NewDiffRedactWriter(w io.Writer) *LineWriter {
+	inDiff := false
+
+	return NewLineWriter(func(line string) {
+		plain := strings.TrimRight(StripANSI(line), "\r\n")
+
+		switch {
+		case strings.HasPrefix(plain, "--- before"):
+			inDiff = true
+		case !inDiff:
+		case strings.HasPrefix(plain, "+++ after"):
+		case strings.HasPrefix(plain, "@@"):
+			_, _ = io.WriteString(w, line)
+			_, _ = io.WriteString(w, "[diff redacted]\n")
+
+			return
+		case plain == "" || strings.HasPrefix(plain, `\ No newline`) || strings.ContainsAny(plain[:1], "+- "):
+			return
+		default:
+			inDiff = false
+		}
+
+		_, _ = io.WriteString(w, line)
+	})
+}
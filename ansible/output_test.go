@@ -0,0 +1,56 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineWriter(t *testing.T) {
+	lines := make([]string, 0)
+	w := NewLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+
+	_, _ = w.Write([]byte("TASK [ping] ***\nok: [lo"))
+	_, _ = w.Write([]byte("calhost]\n\nPLAY RECAP"))
+
+	assert.Equal(t, []string{"TASK [ping] ***\n", "ok: [localhost]\n", "\n"}, lines)
+
+	w.Flush()
+	assert.Equal(t, "PLAY RECAP", lines[3])
+}
+
+func TestStripANSI(t *testing.T) {
+	assert.Equal(t, "changed: [localhost]", StripANSI("\x1b[0;33mchanged: [localhost]\x1b[0m"))
+}
+
+func TestDiffRedactWriter(t *testing.T) {
+	input := "TASK [template] ***\n" +
+		"\x1b[0;31m--- before: /etc/app.conf\x1b[0m\n" +
+		"\x1b[0;32m+++ after: /build/app.conf.j2\x1b[0m\n" +
+		"\x1b[0;36m@@ -1,2 +1,2 @@\x1b[0m\n" +
+		" user = app\n" +
+		"\x1b[0;31m-password = old\x1b[0m\n" +
+		"\x1b[0;32m+password = new\x1b[0m\n" +
+		"\n" +
+		"\x1b[0;33mchanged: [localhost]\x1b[0m\n" +
+		"- not a diff\n"
+
+	want := "TASK [template] ***\n" +
+		"\x1b[0;31m--- before: /etc/app.conf\x1b[0m\n" +
+		"\x1b[0;32m+++ after: /build/app.conf.j2\x1b[0m\n" +
+		"\x1b[0;36m@@ -1,2 +1,2 @@\x1b[0m\n" +
+		"[diff redacted]\n" +
+		"\x1b[0;33mchanged: [localhost]\x1b[0m\n" +
+		"- not a diff\n"
+
+	var b strings.Builder
+
+	w := NewDiffRedactWriter(&b)
+	_, _ = w.Write([]byte(input))
+	w.Flush()
+
+	assert.Equal(t, want, b.String())
+}
@@ -0,0 +1,120 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrAnsiblePlaybookNotFound = errors.New("no playbook found")
+
+// playKeys are the keys of which at least one identifies an item of a playbook as play.
+//
+//nolint:gochecknoglobals
+var playKeys = []string{"hosts", "import_playbook", "ansible.builtin.import_playbook"}
+
+// GetPlaybooks resolves the configured playbook patterns to playbook files. Patterns support
+// `**` to match any number of directories. Files are selected in the order of the patterns
+// and sorted for each pattern, excluded files and duplicates are removed. YAML files matched
+// by a glob pattern that are not playbooks, e.g. vars files, are skipped.
+func (a *Ansible) GetPlaybooks() error {
+	playbooks := make([]string, 0)
+
+	for _, pattern := range a.Playbooks {
+		files, err := doublestar.FilepathGlob(pattern, doublestar.WithFilesOnly())
+		if err != nil {
+			return fmt.Errorf("invalid playbook pattern %q: %w", pattern, err)
+		}
+
+		slices.Sort(files)
+
+		literal := !hasGlobMeta(pattern)
+
+		for _, file := range files {
+			logger := log.Debug().Str("playbook", file).Str("pattern", pattern)
+
+			if exclude := a.matchExclude(file); exclude != "" {
+				logger.Str("exclude", exclude).Msg("playbook excluded")
+
+				continue
+			}
+
+			if slices.Contains(playbooks, file) {
+				logger.Msg("playbook skipped, already selected")
+
+				continue
+			}
+
+			if !literal && !IsPlaybook(file) {
+				logger.Msg("playbook skipped, not a playbook")
+
+				continue
+			}
+
+			logger.Msg("playbook selected")
+
+			playbooks = append(playbooks, file)
+		}
+	}
+
+	if len(playbooks) == 0 {
+		log.Debug().Strs("patterns", a.Playbooks).Strs("excludes", a.PlaybookExcludes).Msg("no playbooks found")
+
+		return ErrAnsiblePlaybookNotFound
+	}
+
+	a.Playbooks = playbooks
+
+	return nil
+}
+
+// IsPlaybook reports whether the file is an ansible playbook, i.e. a YAML list of plays.
+func IsPlaybook(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	var plays []map[string]any
+	if err := yaml.Unmarshal(content, &plays); err != nil || len(plays) == 0 {
+		return false
+	}
+
+	for _, play := range plays {
+		isPlay := false
+
+		for _, key := range playKeys {
+			if _, ok := play[key]; ok {
+				isPlay = true
+
+				break
+			}
+		}
+
+		if !isPlay {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *Ansible) matchExclude(file string) string {
+	for _, pattern := range a.PlaybookExcludes {
+		if ok, _ := doublestar.PathMatch(pattern, file); ok {
+			return pattern
+		}
+	}
+
+	return ""
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[{\`)
+}
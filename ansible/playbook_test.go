@@ -0,0 +1,122 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+func TestGetPlaybooks(t *testing.T) {
+	dir := t.TempDir()
+	play := "- hosts: all\n  tasks: []\n"
+
+	writeFiles(t, dir, map[string]string{
+		"site.yml":                  "- import_playbook: playbooks/web.yml\n",
+		"playbooks/web.yml":         play,
+		"playbooks/db.yml":          play,
+		"playbooks/legacy/old.yml":  play,
+		"playbooks/vars/common.yml": "app_port: 8080\n",
+		"playbooks/tasks/setup.yml": "- name: setup\n  debug:\n",
+	})
+
+	t.Chdir(dir)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		excludes []string
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "literal and glob",
+			patterns: []string{"site.yml", "playbooks/*.yml"},
+			want:     []string{"site.yml", "playbooks/db.yml", "playbooks/web.yml"},
+		},
+		{
+			name:     "recursive glob skips non-playbooks",
+			patterns: []string{"playbooks/**/*.yml"},
+			want:     []string{"playbooks/db.yml", "playbooks/legacy/old.yml", "playbooks/web.yml"},
+		},
+		{
+			name:     "declared order and deduplication",
+			patterns: []string{"playbooks/web.yml", "playbooks/*.yml"},
+			want:     []string{"playbooks/web.yml", "playbooks/db.yml"},
+		},
+		{
+			name:     "excludes",
+			patterns: []string{"**/*.yml"},
+			excludes: []string{"playbooks/legacy/**", "site.yml"},
+			want:     []string{"playbooks/db.yml", "playbooks/web.yml"},
+		},
+		{
+			name:     "literal non-playbook",
+			patterns: []string{"playbooks/vars/common.yml"},
+			want:     []string{"playbooks/vars/common.yml"},
+		},
+		{
+			name:     "not found",
+			patterns: []string{"missing.yml", "missing/*.yml"},
+			wantErr:  ErrAnsiblePlaybookNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Ansible{Playbooks: tt.patterns, PlaybookExcludes: tt.excludes}
+
+			err := a.GetPlaybooks()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, a.Playbooks)
+		})
+	}
+}
+
+func TestIsPlaybook(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFiles(t, dir, map[string]string{
+		"play.yml":   "- name: deploy\n  hosts: web\n- ansible.builtin.import_playbook: db.yml\n",
+		"vars.yml":   "app_port: 8080\n",
+		"tasks.yml":  "- name: setup\n  debug:\n",
+		"empty.yml":  "",
+		"broken.yml": "- hosts: [\n",
+	})
+
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{file: "play.yml", want: true},
+		{file: "vars.yml", want: false},
+		{file: "tasks.yml", want: false},
+		{file: "empty.yml", want: false},
+		{file: "broken.yml", want: false},
+		{file: "missing.yml", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsPlaybook(filepath.Join(dir, tt.file)))
+		})
+	}
+}
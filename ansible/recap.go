@@ -0,0 +1,130 @@
+package ansible
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//nolint:gochecknoglobals
+var (
+	recapHostPattern = regexp.MustCompile(`^(\S+)\s+:\s+((?:\w+=\d+\s*)+)$`)
+	recapStatPattern = regexp.MustCompile(`(\w+)=(\d+)`)
+)
+
+// HostStats are the task counters of a host from the play recap.
+type HostStats struct {
+	Host        string `json:"host"`
+	Ok          int    `json:"ok"`
+	Changed     int    `json:"changed"`
+	Unreachable int    `json:"unreachable"`
+	Failed      int    `json:"failed"`
+	Skipped     int    `json:"skipped"`
+	Rescued     int    `json:"rescued"`
+	Ignored     int    `json:"ignored"`
+}
+
+// Recap holds the host stats of the play recaps of a playbook run.
+type Recap struct {
+	Hosts []*HostStats
+
+	inRecap bool
+}
+
+// NewRecapWriter creates a LineWriter that collects the play recap of the output.
+func NewRecapWriter(recap *Recap) *LineWriter {
+	return NewLineWriter(recap.parseLine)
+}
+
+func (r *Recap) parseLine(line string) {
+	plain := strings.TrimSpace(StripANSI(line))
+
+	switch {
+	case strings.HasPrefix(plain, "PLAY RECAP"):
+		r.inRecap = true
+	case !r.inRecap:
+	case plain == "":
+		r.inRecap = false
+	default:
+		match := recapHostPattern.FindStringSubmatch(plain)
+		if match == nil {
+			return
+		}
+
+		stats := r.host(match[1])
+
+		for _, stat := range recapStatPattern.FindAllStringSubmatch(match[2], -1) {
+			value, _ := strconv.Atoi(stat[2])
+
+			switch stat[1] {
+			case "ok":
+				stats.Ok += value
+			case "changed":
+				stats.Changed += value
+			case "unreachable":
+				stats.Unreachable += value
+			case "failed":
+				stats.Failed += value
+			case "skipped":
+				stats.Skipped += value
+			case "rescued":
+				stats.Rescued += value
+			case "ignored":
+				stats.Ignored += value
+			}
+		}
+	}
+}
+
+// Merge adds the host stats of another recap.
+func (r *Recap) Merge(other *Recap) {
+	for _, s := range other.Hosts {
+		stats := r.host(s.Host)
+		stats.Ok += s.Ok
+		stats.Changed += s.Changed
+		stats.Unreachable += s.Unreachable
+		stats.Failed += s.Failed
+		stats.Skipped += s.Skipped
+		stats.Rescued += s.Rescued
+		stats.Ignored += s.Ignored
+	}
+}
+
+// Changed returns the hosts with changed tasks.
+func (r *Recap) Changed() []string {
+	hosts := make([]string, 0)
+
+	for _, s := range r.Hosts {
+		if s.Changed > 0 {
+			hosts = append(hosts, s.Host)
+		}
+	}
+
+	return hosts
+}
+
+// Failed returns the hosts with failed tasks or that were unreachable.
+func (r *Recap) Failed() []string {
+	hosts := make([]string, 0)
+
+	for _, s := range r.Hosts {
+		if s.Failed > 0 || s.Unreachable > 0 {
+			hosts = append(hosts, s.Host)
+		}
+	}
+
+	return hosts
+}
+
+func (r *Recap) host(name string) *HostStats {
+	for _, s := range r.Hosts {
+		if s.Host == name {
+			return s
+		}
+	}
+
+	s := &HostStats{Host: name}
+	r.Hosts = append(r.Hosts, s)
+
+	return s
+}
@@ -0,0 +1,48 @@
+package ansible
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const recapOutput = `PLAY [webservers] **************************************************************
+
+TASK [nginx : install] *********************************************************
+changed: [web1]
+ok: [web2]
+
+PLAY RECAP *********************************************************************
+web1                       : ok=3    changed=1    unreachable=0    failed=0    skipped=0    rescued=0    ignored=0
+web2                       : ok=2    changed=0    unreachable=0    failed=1    skipped=1    rescued=0    ignored=0
+` + "\x1b[0;31mdb1\x1b[0m                        : ok=0    changed=0    " +
+	"\x1b[1;31munreachable=1\x1b[0m    failed=0\n" + `
+Done: not a recap line : ok=1
+`
+
+func TestRecapWriter(t *testing.T) {
+	recap := &Recap{}
+	w := NewRecapWriter(recap)
+
+	_, _ = io.WriteString(w, recapOutput)
+	w.Flush()
+
+	assert.Equal(t, []*HostStats{
+		{Host: "web1", Ok: 3, Changed: 1},
+		{Host: "web2", Ok: 2, Failed: 1, Skipped: 1},
+		{Host: "db1", Unreachable: 1},
+	}, recap.Hosts)
+	assert.Equal(t, []string{"web1"}, recap.Changed())
+	assert.Equal(t, []string{"web2", "db1"}, recap.Failed())
+}
+
+func TestRecapMerge(t *testing.T) {
+	recap := &Recap{Hosts: []*HostStats{{Host: "web1", Ok: 3, Changed: 1}}}
+	recap.Merge(&Recap{Hosts: []*HostStats{{Host: "web1", Ok: 1, Failed: 1}, {Host: "web2", Ok: 2}}})
+
+	assert.Equal(t, []*HostStats{
+		{Host: "web1", Ok: 4, Changed: 1, Failed: 1},
+		{Host: "web2", Ok: 2},
+	}, recap.Hosts)
+}
@@ -0,0 +1,209 @@
+package ansible
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	StatusOk          = "ok"
+	StatusChanged     = "changed"
+	StatusSkipped     = "skipped"
+	StatusFailed      = "failed"
+	StatusUnreachable = "unreachable"
+	StatusIgnored     = "ignored"
+	StatusRescued     = "rescued"
+)
+
+//nolint:gochecknoglobals
+var (
+	playPattern   = regexp.MustCompile(`^PLAY \[(.*)\] \**$`)
+	taskPattern   = regexp.MustCompile(`^(TASK|RUNNING HANDLER) \[(.*)\] \**$`)
+	resultPattern = regexp.MustCompile(`^(ok|changed|skipping|fatal|failed): \[([^\]]+)\](: UNREACHABLE!)?`)
+
+	// statusRank orders the results of a host, e.g. of loop items, by severity.
+	statusRank = map[string]int{
+		StatusSkipped:     0,
+		StatusOk:          1,
+		StatusChanged:     2, //nolint:mnd
+		StatusIgnored:     3, //nolint:mnd
+		StatusFailed:      4, //nolint:mnd
+		StatusUnreachable: 5, //nolint:mnd
+	}
+)
+
+// Timeline holds the plays, tasks and host results of a playbook run with the time
+// they were printed.
+type Timeline struct {
+	Plays []*PlayResult
+
+	now  func() time.Time
+	play *PlayResult
+	task *TaskResult
+}
+
+// PlayResult is a play of a playbook run.
+type PlayResult struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	Tasks []*TaskResult
+}
+
+// TaskResult is a task or handler of a play.
+type TaskResult struct {
+	Name    string
+	Handler bool
+	Start   time.Time
+	End     time.Time
+	Hosts   []*HostResult
+}
+
+// HostResult is the result of a task on a host. Host results are printed when the task
+// finished on the host.
+type HostResult struct {
+	Host   string
+	Status string
+	End    time.Time
+}
+
+// NewTimelineWriter creates a LineWriter that collects the timeline of the output of
+// the default callback.
+func NewTimelineWriter(timeline *Timeline) *LineWriter {
+	return NewLineWriter(timeline.parseLine)
+}
+
+// Finish ends the open task and play.
+func (t *Timeline) Finish() {
+	now := t.clock()
+
+	t.endTask(now)
+	t.endPlay(now)
+}
+
+// Tasks returns the tasks of all plays.
+func (t *Timeline) Tasks() []*TaskResult {
+	tasks := make([]*TaskResult, 0)
+
+	for _, play := range t.Plays {
+		tasks = append(tasks, play.Tasks...)
+	}
+
+	return tasks
+}
+
+// Duration returns the time between the start of the task and the result of the host.
+func (r *HostResult) Duration(task *TaskResult) time.Duration {
+	return r.End.Sub(task.Start)
+}
+
+func (t *Timeline) parseLine(line string) {
+	plain := strings.TrimRight(StripANSI(line), " \r\n")
+	now := t.clock()
+
+	if match := playPattern.FindStringSubmatch(plain); match != nil {
+		t.endTask(now)
+		t.endPlay(now)
+
+		t.play = &PlayResult{Name: match[1], Start: now}
+		t.Plays = append(t.Plays, t.play)
+
+		return
+	}
+
+	if match := taskPattern.FindStringSubmatch(plain); match != nil {
+		t.endTask(now)
+
+		if t.play == nil {
+			t.play = &PlayResult{Start: now}
+			t.Plays = append(t.Plays, t.play)
+		}
+
+		t.task = &TaskResult{Name: match[2], Handler: match[1] == "RUNNING HANDLER", Start: now}
+		t.play.Tasks = append(t.play.Tasks, t.task)
+
+		return
+	}
+
+	if strings.HasPrefix(plain, "PLAY RECAP") {
+		t.Finish()
+
+		return
+	}
+
+	if t.task == nil {
+		return
+	}
+
+	if plain == "...ignoring" {
+		for _, result := range t.task.Hosts {
+			if result.Status == StatusFailed {
+				result.Status = StatusIgnored
+			}
+		}
+
+		return
+	}
+
+	if match := resultPattern.FindStringSubmatch(plain); match != nil {
+		t.addResult(match, now)
+	}
+}
+
+func (t *Timeline) addResult(match []string, now time.Time) {
+	host, _, _ := strings.Cut(match[2], " -> ")
+
+	status := match[1]
+
+	switch {
+	case match[3] != "":
+		status = StatusUnreachable
+	case status == "skipping":
+		status = StatusSkipped
+	case status == "fatal":
+		status = StatusFailed
+	}
+
+	for _, result := range t.task.Hosts {
+		if result.Host != host {
+			continue
+		}
+
+		result.End = now
+
+		if statusRank[status] > statusRank[result.Status] {
+			result.Status = status
+		}
+
+		return
+	}
+
+	t.task.Hosts = append(t.task.Hosts, &HostResult{Host: host, Status: status, End: now})
+}
+
+func (t *Timeline) endTask(now time.Time) {
+	if t.task == nil {
+		return
+	}
+
+	t.task.End = now
+	t.task = nil
+}
+
+func (t *Timeline) endPlay(now time.Time) {
+	if t.play == nil {
+		return
+	}
+
+	t.play.End = now
+	t.play = nil
+}
+
+func (t *Timeline) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+
+	return time.Now()
+}
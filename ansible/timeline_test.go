@@ -0,0 +1,91 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const timelineOutput = `PLAY [webservers] **************************************************************
+
+TASK [Gathering Facts] *********************************************************
+ok: [web1]
+fatal: [web2]: UNREACHABLE! => {"changed": false, "unreachable": true}
+
+TASK [nginx : install packages] ************************************************
+changed: [web1] => (item=nginx)
+ok: [web1] => (item=certbot)
+
+TASK [nginx : check config] ****************************************************
+fatal: [web1]: FAILED! => {"changed": false}
+...ignoring
+
+RUNNING HANDLER [nginx : reload] ***********************************************
+changed: [web1 -> localhost]
+
+PLAY [dbservers] ***************************************************************
+
+TASK [postgres : install] ******************************************************
+skipping: [db1]
+
+PLAY RECAP *********************************************************************
+web1                       : ok=4    changed=2    unreachable=0    failed=0    skipped=0    rescued=0    ignored=1
+`
+
+func TestTimelineWriter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tick := 0
+	timeline := &Timeline{now: func() time.Time {
+		tick++
+
+		return start.Add(time.Duration(tick) * time.Second)
+	}}
+
+	w := NewTimelineWriter(timeline)
+
+	for _, line := range strings.SplitAfter(timelineOutput, "\n") {
+		_, _ = w.Write([]byte(line))
+	}
+
+	w.Flush()
+
+	require.Len(t, timeline.Plays, 2)
+	assert.Equal(t, "webservers", timeline.Plays[0].Name)
+	assert.Equal(t, "dbservers", timeline.Plays[1].Name)
+
+	type result struct {
+		task    string
+		handler bool
+		hosts   map[string]string
+	}
+
+	got := make([]result, 0)
+
+	for _, task := range timeline.Tasks() {
+		hosts := make(map[string]string)
+		for _, host := range task.Hosts {
+			hosts[host.Host] = host.Status
+
+			assert.Positive(t, host.Duration(task))
+		}
+
+		assert.True(t, task.End.After(task.Start))
+
+		got = append(got, result{task: task.Name, handler: task.Handler, hosts: hosts})
+	}
+
+	assert.Equal(t, []result{
+		{task: "Gathering Facts", hosts: map[string]string{"web1": StatusOk, "web2": StatusUnreachable}},
+		{task: "nginx : install packages", hosts: map[string]string{"web1": StatusChanged}},
+		{task: "nginx : check config", hosts: map[string]string{"web1": StatusIgnored}},
+		{task: "nginx : reload", handler: true, hosts: map[string]string{"web1": StatusChanged}},
+		{task: "postgres : install", hosts: map[string]string{"db1": StatusSkipped}},
+	}, got)
+
+	for _, play := range timeline.Plays {
+		assert.False(t, play.End.IsZero())
+	}
+}
@@ -0,0 +1,156 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Feature is an ansible feature the plugin depends on.
+type Feature string
+
+const (
+	FeatureGalaxySubcommands Feature = "galaxy role and collection subcommands"
+	FeatureGalaxyCollections Feature = "galaxy collections"
+	FeatureGalaxyOffline     Feature = "galaxy offline install"
+	FeatureCollectionsPath   Feature = "ANSIBLE_COLLECTIONS_PATH"
+)
+
+var (
+	ErrAnsibleVersionUnknown     = errors.New("unable to parse ansible version")
+	ErrAnsibleFeatureUnsupported = errors.New("not supported by the installed ansible version")
+
+	// featureVersions maps the features to the ansible version they were introduced in.
+	featureVersions = map[Feature]*semver.Version{ //nolint:gochecknoglobals
+		FeatureGalaxySubcommands: semver.MustParse("2.9.0"),
+		FeatureGalaxyCollections: semver.MustParse("2.9.0"),
+		FeatureGalaxyOffline:     semver.MustParse("2.14.0"),
+		FeatureCollectionsPath:   semver.MustParse("2.10.0"),
+	}
+
+	coreVersionPattern  = regexp.MustCompile(`^ansible (?:\[core )?([^\s\]]+)`)
+	preReleasePattern   = regexp.MustCompile(`^(\d+(?:\.\d+)*?)\.?([a-z][0-9a-z.]*)$`)
+	versionFieldPattern = regexp.MustCompile(`^\s+([^=]+?)\s+=\s+(.*)$`)
+)
+
+// VersionInfo holds the parsed output of the version command.
+type VersionInfo struct {
+	Core            *semver.Version
+	Python          string
+	ConfigFile      string
+	CollectionPaths []string
+}
+
+// ParseVersion parses the output of the version command.
+func ParseVersion(out []byte) (*VersionInfo, error) {
+	info := &VersionInfo{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := coreVersionPattern.FindStringSubmatch(line); match != nil {
+			core, err := ParseCoreVersion(match[1])
+			if err != nil {
+				return nil, err
+			}
+
+			info.Core = core
+
+			continue
+		}
+
+		match := versionFieldPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		switch value := match[2]; match[1] {
+		case "config file":
+			if value != "None" {
+				info.ConfigFile = value
+			}
+		case "ansible collection location":
+			info.CollectionPaths = strings.Split(value, string(os.PathListSeparator))
+		case "python version":
+			info.Python, _, _ = strings.Cut(value, " ")
+		}
+	}
+
+	if info.Core == nil {
+		return nil, ErrAnsibleVersionUnknown
+	}
+
+	return info, nil
+}
+
+// ParseCoreVersion parses an ansible-core version, including python style
+// pre-releases like `2.18.0rc1` or `2.18.0.dev0`.
+func ParseCoreVersion(version string) (*semver.Version, error) {
+	if match := preReleasePattern.FindStringSubmatch(version); match != nil {
+		version = fmt.Sprintf("%s-%s", match[1], match[2])
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrAnsibleVersionUnknown, version, err)
+	}
+
+	return v, nil
+}
+
+// Supports reports whether the ansible version supports the given feature.
+// Features are considered supported if the version is unknown.
+func (a *Ansible) Supports(feature Feature) bool {
+	if a.VersionInfo == nil {
+		return true
+	}
+
+	// Pre-releases of a version already ship its features.
+	core, _ := a.VersionInfo.Core.SetPrerelease("")
+
+	return !core.LessThan(featureVersions[feature])
+}
+
+// RequireFeature returns an error naming the feature and its minimum version if the
+// ansible version does not support it.
+func (a *Ansible) RequireFeature(feature Feature) error {
+	if a.Supports(feature) {
+		return nil
+	}
+
+	return fmt.Errorf("%s %w (requires %s)", feature, ErrAnsibleFeatureUnsupported, featureVersions[feature])
+}
+
+// CheckFeatures returns an error if the configured options require features
+// the ansible version does not support.
+func (a *Ansible) CheckFeatures() error {
+	if a.GalaxyOffline {
+		if err := a.RequireFeature(FeatureGalaxyOffline); err != nil {
+			return err
+		}
+	}
+
+	if a.Supports(FeatureGalaxyCollections) {
+		return nil
+	}
+
+	for _, file := range a.GalaxyRequirementFiles() {
+		req, err := ReadGalaxyRequirements(file)
+		if err != nil {
+			return err
+		}
+
+		if len(req.Collections) > 0 {
+			return a.RequireFeature(FeatureGalaxyCollections)
+		}
+	}
+
+	return nil
+}
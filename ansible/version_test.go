@@ -0,0 +1,169 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const versionOutput = `ansible [core 2.17.1]
+  config file = /build/ansible.cfg
+  configured module search path = ['/root/.ansible/plugins/modules', '/usr/share/ansible/plugins/modules']
+  ansible python module location = /usr/local/lib/python3.12/site-packages/ansible
+  ansible collection location = /root/.ansible/collections:/usr/share/ansible/collections
+  executable location = /usr/local/bin/ansible
+  python version = 3.12.4 (main, Jun 27 2024, 00:07:37) [GCC 13.2.1 20240309] (/usr/local/bin/python3)
+  jinja version = 3.1.4
+  libyaml = True
+`
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     string
+		want    *VersionInfo
+		wantErr error
+	}{
+		{
+			name: "ansible-core",
+			out:  versionOutput,
+			want: &VersionInfo{
+				Core:            semver.MustParse("2.17.1"),
+				Python:          "3.12.4",
+				ConfigFile:      "/build/ansible.cfg",
+				CollectionPaths: []string{"/root/.ansible/collections", "/usr/share/ansible/collections"},
+			},
+		},
+		{
+			name: "legacy ansible without config file",
+			out:  "ansible 2.9.27\n  config file = None\n  python version = 3.8.10 (default)\n",
+			want: &VersionInfo{
+				Core:   semver.MustParse("2.9.27"),
+				Python: "3.8.10",
+			},
+		},
+		{
+			name:    "invalid output",
+			out:     "command not found",
+			wantErr: ErrAnsibleVersionUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVersion([]byte(tt.out))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, tt.want.Core.Equal(got.Core))
+			assert.Equal(t, tt.want.Python, got.Python)
+			assert.Equal(t, tt.want.ConfigFile, got.ConfigFile)
+			assert.Equal(t, tt.want.CollectionPaths, got.CollectionPaths)
+		})
+	}
+}
+
+func TestParseCoreVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "2.17.1", want: "2.17.1"},
+		{version: "2.18.0rc1", want: "2.18.0-rc1"},
+		{version: "2.19.0.dev0", want: "2.19.0-dev0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseCoreVersion(tt.version)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		feature Feature
+		want    bool
+	}{
+		{name: "unknown version", feature: FeatureGalaxyOffline, want: true},
+		{name: "supported", version: "2.17.1", feature: FeatureGalaxyOffline, want: true},
+		{name: "unsupported", version: "2.13.9", feature: FeatureGalaxyOffline, want: false},
+		{name: "pre-release", version: "2.14.0rc1", feature: FeatureGalaxyOffline, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Ansible{}
+
+			if tt.version != "" {
+				core, err := ParseCoreVersion(tt.version)
+				require.NoError(t, err)
+
+				a.VersionInfo = &VersionInfo{Core: core}
+			}
+
+			assert.Equal(t, tt.want, a.Supports(tt.feature))
+		})
+	}
+}
+
+func TestCheckFeatures(t *testing.T) {
+	req := filepath.Join(t.TempDir(), "requirements.yml")
+	require.NoError(t, os.WriteFile(req, []byte("collections:\n  - community.general\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		version string
+		ansible *Ansible
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "offline with supported version",
+			version: "2.14.0",
+			ansible: &Ansible{GalaxyOffline: true},
+		},
+		{
+			name:    "offline with unsupported version",
+			version: "2.13.0",
+			ansible: &Ansible{GalaxyOffline: true},
+			wantErr: ErrAnsibleFeatureUnsupported,
+			wantMsg: "galaxy offline install not supported by the installed ansible version (requires 2.14.0)",
+		},
+		{
+			name:    "collections with unsupported version",
+			version: "2.8.20",
+			ansible: &Ansible{GalaxyRequirements: req},
+			wantErr: ErrAnsibleFeatureUnsupported,
+			wantMsg: "galaxy collections not supported by the installed ansible version (requires 2.9.0)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.ansible.VersionInfo = &VersionInfo{Core: semver.MustParse(tt.version)}
+
+			err := tt.ansible.CheckFeatures()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.EqualError(t, err, tt.wantMsg)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultInterval = 10 * time.Second
+
+var (
+	ErrApprovalDenied   = errors.New("approval denied")
+	ErrApprovalTimeout  = errors.New("timeout waiting for approval")
+	ErrApprovalEndpoint = errors.New("approval endpoint error")
+)
+
+// Status is the state of an approval request.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusDenied   Status = "denied"
+)
+
+// Request describes the changes that require approval.
+type Request struct {
+	ID          string    `json:"id"`
+	Commit      string    `json:"commit"`
+	Branch      string    `json:"branch"`
+	Author      string    `json:"author"`
+	Pipeline    string    `json:"pipeline"`
+	Inventories []string  `json:"inventories"`
+	Playbooks   []string  `json:"playbooks"`
+	Summary     string    `json:"summary"`
+	Created     time.Time `json:"created"`
+}
+
+// Decision is the response of the approval endpoint.
+type Decision struct {
+	Status   Status `json:"status"`
+	Approver string `json:"approver,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Client requests approvals from an HTTP endpoint. A request is submitted by a POST
+// request with the request as JSON body to `<url>`, the state of a request is polled
+// by GET requests to `<url>/<id>`. Both respond with the decision as JSON body, an
+// empty body is treated as pending.
+type Client struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+// Options configure how long to wait for a decision.
+type Options struct {
+	// Timeout is the maximum time to wait for a decision.
+	Timeout time.Duration
+	// Interval is the time between polls of the request state.
+	Interval time.Duration
+}
+
+// NewRequest creates a request with a random ID.
+func NewRequest() (*Request, error) {
+	id := make([]byte, 8) //nolint:mnd
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &Request{ID: hex.EncodeToString(id), Created: time.Now().UTC()}, nil
+}
+
+// Wait submits the request and waits until it is approved, denied or the timeout is
+// exceeded. An error is returned unless the request was approved.
+func Wait(ctx context.Context, client *Client, req *Request, opts Options) (*Decision, error) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	decision, err := client.Submit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("id", req.ID).Msg("approval requested, waiting for decision")
+
+	for {
+		switch decision.Status {
+		case StatusApproved:
+			log.Info().Str("id", req.ID).Str("approver", decision.Approver).Msg("approval granted")
+
+			return decision, nil
+		case StatusDenied:
+			return decision, fmt.Errorf("%w by %s: %s", ErrApprovalDenied, decision.Approver, decision.Reason)
+		case StatusPending, "":
+		default:
+			return nil, fmt.Errorf("%w: unknown status %q", ErrApprovalEndpoint, decision.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %s", ErrApprovalTimeout, req.ID)
+		case <-time.After(interval):
+		}
+
+		decision, err = client.Status(ctx, req.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrApprovalTimeout, req.ID, err)
+			}
+
+			return nil, err
+		}
+	}
+}
+
+// Submit submits the request to the endpoint.
+func (c *Client) Submit(ctx context.Context, req *Request) (*Decision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(ctx, http.MethodPost, strings.TrimSuffix(c.URL, "/"), body)
+}
+
+// Status returns the current decision for the request.
+func (c *Client) Status(ctx context.Context, id string) (*Decision, error) {
+	return c.do(ctx, http.MethodGet, fmt.Sprintf("%s/%s", strings.TrimSuffix(c.URL, "/"), url.PathEscape(id)), nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body []byte) (*Decision, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrApprovalEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:mnd
+
+		return nil, fmt.Errorf("%w: unexpected status %s: %s", ErrApprovalEndpoint, resp.Status,
+			strings.TrimSpace(string(msg)))
+	}
+
+	decision := &Decision{Status: StatusPending}
+
+	if err := json.NewDecoder(resp.Body).Decode(decision); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: invalid decision: %w", ErrApprovalEndpoint, err)
+	}
+
+	return decision, nil
+}
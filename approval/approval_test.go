@@ -0,0 +1,126 @@
+package approval
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApprovalServer creates a stand-in for an approval endpoint that answers with the
+// given decisions in order, the last decision is repeated.
+func newApprovalServer(t *testing.T, decisions ...*Decision) (*httptest.Server, *[]*Request) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		requests []*Request
+		polls    int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			req := &Request{}
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			requests = append(requests, req)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodGet:
+			if len(requests) == 0 || r.URL.Path != "/approvals/"+requests[0].ID {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(decisions[min(polls, len(decisions)-1)])
+			polls++
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		decisions []*Decision
+		want      *Decision
+		wantErr   error
+	}{
+		{
+			name:      "approved",
+			token:     "secret",
+			decisions: []*Decision{{Status: StatusPending}, {Status: StatusApproved, Approver: "jane"}},
+			want:      &Decision{Status: StatusApproved, Approver: "jane"},
+		},
+		{
+			name:      "denied",
+			token:     "secret",
+			decisions: []*Decision{{Status: StatusDenied, Approver: "jane", Reason: "wrong host"}},
+			wantErr:   ErrApprovalDenied,
+		},
+		{
+			name:      "timeout",
+			token:     "secret",
+			decisions: []*Decision{{Status: StatusPending}},
+			wantErr:   ErrApprovalTimeout,
+		},
+		{
+			name:      "unknown status",
+			token:     "secret",
+			decisions: []*Decision{{Status: "maybe"}},
+			wantErr:   ErrApprovalEndpoint,
+		},
+		{
+			name:      "unauthorized",
+			decisions: []*Decision{{Status: StatusApproved}},
+			wantErr:   ErrApprovalEndpoint,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newApprovalServer(t, tt.decisions...)
+			client := &Client{URL: server.URL + "/approvals/", Token: tt.token}
+
+			req, err := NewRequest()
+			require.NoError(t, err)
+
+			req.Summary = "changed: [web1]"
+			opts := Options{Timeout: 200 * time.Millisecond, Interval: 10 * time.Millisecond}
+
+			got, err := Wait(t.Context(), client, req, opts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			require.Len(t, *requests, 1)
+			assert.Equal(t, req.Summary, (*requests)[0].Summary)
+		})
+	}
+}
@@ -0,0 +1,397 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskListKeys are the keys of a play holding task lists.
+//
+//nolint:gochecknoglobals
+var taskListKeys = []string{"pre_tasks", "tasks", "post_tasks", "handlers"}
+
+// Options configure how references of playbooks are resolved.
+type Options struct {
+	// RolesPath holds additional directories to search for roles.
+	RolesPath []string
+	// Inventories are added with their group_vars and host_vars as global dependencies.
+	Inventories []string
+	// Global holds additional files all playbooks depend on, e.g. requirements files.
+	Global []string
+	// ExternalRoles holds the names of roles installed at runtime, e.g. from galaxy. These
+	// roles are not reported as missing.
+	ExternalRoles []string
+}
+
+type builder struct {
+	graph   *Graph
+	opts    Options
+	visited map[string]bool
+}
+
+// Build parses the playbooks and creates the graph of their dependencies.
+func Build(playbooks []string, opts Options) (*Graph, error) {
+	b := &builder{
+		graph:   New(),
+		opts:    opts,
+		visited: make(map[string]bool),
+	}
+
+	for _, inventory := range opts.Inventories {
+		inventory = filepath.Clean(inventory)
+		b.graph.Global = append(b.graph.Global, inventory)
+
+		if info, err := os.Stat(inventory); err == nil && !info.IsDir() {
+			dir := filepath.Dir(inventory)
+			b.graph.Global = append(b.graph.Global, filepath.Join(dir, "group_vars"), filepath.Join(dir, "host_vars"))
+		}
+	}
+
+	for _, file := range opts.Global {
+		if file != "" {
+			b.graph.Global = append(b.graph.Global, filepath.Clean(file))
+		}
+	}
+
+	for _, playbook := range playbooks {
+		playbook = filepath.Clean(playbook)
+		b.graph.Playbooks = append(b.graph.Playbooks, playbook)
+
+		if err := b.playbook(playbook); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.graph, nil
+}
+
+func (b *builder) playbook(path string) error {
+	node := b.graph.addNode(path, KindPlaybook, false)
+	if b.visit(path) {
+		return nil
+	}
+
+	var plays []map[string]any
+
+	if err := readYAML(path, &plays); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			node.Missing = true
+
+			return nil
+		}
+
+		return err
+	}
+
+	dir := filepath.Dir(path)
+
+	for _, varsDir := range []string{"group_vars", "host_vars"} {
+		b.reference(path, filepath.Join(dir, varsDir), KindVars, true)
+		b.graph.Nodes[filepath.Join(dir, varsDir)].Optional = true
+	}
+
+	for _, play := range plays {
+		if err := b.play(path, dir, play); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *builder) play(path, dir string, play map[string]any) error {
+	for key, value := range play {
+		switch moduleName(key) {
+		case "import_playbook":
+			ref := argString(value, "")
+			if isTemplated(ref) {
+				continue
+			}
+
+			target := filepath.Join(dir, ref)
+			b.graph.addEdge(path, target)
+
+			if err := b.playbook(target); err != nil {
+				return err
+			}
+		case "vars_files":
+			for _, file := range toList(value) {
+				if ref, ok := file.(string); ok && !isTemplated(ref) {
+					b.reference(path, filepath.Join(dir, ref), KindVars, false)
+				}
+			}
+		case "roles":
+			for _, role := range toList(value) {
+				if err := b.role(path, dir, roleName(role)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, key := range taskListKeys {
+		if err := b.tasks(path, dir, "", toList(play[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tasks resolves the references of a task list. Files referenced by tasks of a role
+// are not resolved, as the role depends on its whole directory.
+func (b *builder) tasks(from, dir, role string, tasks []any) error {
+	for _, item := range tasks {
+		task, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		for key, value := range task {
+			if err := b.task(from, dir, role, moduleName(key), value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//nolint:gocyclo
+func (b *builder) task(from, dir, role, module string, value any) error {
+	switch module {
+	case "block", "rescue", "always":
+		return b.tasks(from, dir, role, toList(value))
+	case "include_role", "import_role":
+		return b.role(from, dir, argString(value, "name"))
+	}
+
+	if role != "" {
+		return nil
+	}
+
+	ref := argString(value, "src")
+
+	switch module {
+	case "include_tasks", "import_tasks":
+		ref = argString(value, "file")
+	case "include_vars":
+		ref = argString(value, "file")
+	case "template", "copy":
+		if argBool(value, "remote_src") {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if ref == "" || isTemplated(ref) {
+		return nil
+	}
+
+	switch module {
+	case "include_tasks", "import_tasks":
+		target := filepath.Join(dir, ref)
+		if b.reference(from, target, KindTasks, false) || b.visit(target) {
+			return nil
+		}
+
+		var tasks []any
+		if err := readYAML(target, &tasks); err != nil {
+			return err
+		}
+
+		return b.tasks(target, filepath.Dir(target), "", tasks)
+	case "include_vars":
+		b.reference(from, resolve(filepath.Join(dir, "vars", ref), filepath.Join(dir, ref)), KindVars, false)
+	case "template":
+		b.reference(from, resolve(filepath.Join(dir, "templates", ref), filepath.Join(dir, ref)), KindTemplate, false)
+	case "copy":
+		b.reference(from, resolve(filepath.Join(dir, "files", ref), filepath.Join(dir, ref)), KindFile, false)
+	}
+
+	return nil
+}
+
+func (b *builder) role(from, dir, name string) error {
+	if name == "" || isTemplated(name) {
+		return nil
+	}
+
+	candidates := []string{filepath.Join(dir, "roles", name)}
+	for _, rolesPath := range b.opts.RolesPath {
+		candidates = append(candidates, filepath.Join(rolesPath, name))
+	}
+
+	candidates = append(candidates, filepath.Join(dir, name))
+
+	path := resolve(candidates...)
+	if !exists(path) {
+		// Roles that can not be found locally are referenced by name, as they
+		// are usually installed from galaxy.
+		path = name
+	}
+
+	if b.reference(from, path, KindRole, true) {
+		// Roles of collections are referenced by their fully qualified name.
+		b.graph.Nodes[path].Optional = strings.Count(name, ".") >= 2 || slices.Contains(b.opts.ExternalRoles, name)
+
+		return nil
+	}
+
+	if b.visit(path) {
+		return nil
+	}
+
+	var meta struct {
+		Dependencies []any `yaml:"dependencies"`
+	}
+
+	if err := readYAML(filepath.Join(path, "meta", "main.yml"), &meta); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	for _, dep := range meta.Dependencies {
+		if err := b.role(path, filepath.Dir(path), roleName(dep)); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range []string{"tasks", "handlers"} {
+		files, _ := filepath.Glob(filepath.Join(path, sub, "*.yml"))
+
+		for _, file := range files {
+			var tasks []any
+			if err := readYAML(file, &tasks); err != nil {
+				return err
+			}
+
+			if err := b.tasks(path, filepath.Dir(path), path, tasks); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// reference adds a node and an edge to it and reports whether the referenced path is missing.
+func (b *builder) reference(from, path string, kind Kind, dir bool) bool {
+	node := b.graph.addNode(path, kind, dir)
+	node.Missing = !exists(path)
+
+	b.graph.addEdge(from, path)
+
+	return node.Missing
+}
+
+// visit marks the path as visited and reports whether it was visited before.
+func (b *builder) visit(path string) bool {
+	if b.visited[path] {
+		return true
+	}
+
+	b.visited[path] = true
+
+	return false
+}
+
+func readYAML(path string, out any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// resolve returns the first existing path, or the last path if none exists.
+func resolve(paths ...string) string {
+	for _, path := range paths {
+		if exists(path) {
+			return path
+		}
+	}
+
+	return paths[len(paths)-1]
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+
+	return err == nil
+}
+
+// moduleName returns the short name of builtin modules and keywords.
+func moduleName(key string) string {
+	return strings.TrimPrefix(key, "ansible.builtin.")
+}
+
+// roleName returns the name of a role reference in short or dict form.
+func roleName(value any) string {
+	if name := argString(value, "role"); name != "" {
+		return name
+	}
+
+	return argString(value, "name")
+}
+
+// argString returns a module argument given as dict, as `key=value` free-form string
+// or, if key is empty or the free-form has no `key=`, the plain string value.
+func argString(value any, key string) string {
+	switch v := value.(type) {
+	case string:
+		if key == "" {
+			return v
+		}
+
+		for _, field := range strings.Fields(v) {
+			if arg, ok := strings.CutPrefix(field, key+"="); ok {
+				return arg
+			}
+		}
+
+		if !strings.Contains(v, "=") {
+			return v
+		}
+	case map[string]any:
+		if arg, ok := v[key]; ok {
+			return fmt.Sprint(arg)
+		}
+	}
+
+	return ""
+}
+
+// argBool reports whether a module argument is set to a value Ansible treats as true.
+func argBool(value any, key string) bool {
+	switch strings.ToLower(argString(value, key)) {
+	case "true", "yes", "on", "y", "t", "1":
+		return true
+	default:
+		return false
+	}
+}
+
+func toList(value any) []any {
+	if list, ok := value.([]any); ok {
+		return list
+	}
+
+	return nil
+}
+
+func isTemplated(s string) bool {
+	return strings.Contains(s, "{{")
+}
@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type jsonGraph struct {
+	Playbooks []string   `json:"playbooks"`
+	Nodes     []*Node    `json:"nodes"`
+	Edges     []jsonEdge `json:"edges"`
+}
+
+type jsonEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SortedNodes returns the nodes of the graph sorted by ID.
+func (g *Graph) SortedNodes() []*Node {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		nodes = append(nodes, node)
+	}
+
+	slices.SortFunc(nodes, func(a, b *Node) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return nodes
+}
+
+// Missing returns the referenced files and roles that do not exist, except optional ones.
+func (g *Graph) Missing() []*Node {
+	missing := make([]*Node, 0)
+
+	for _, node := range g.SortedNodes() {
+		if node.Missing && !node.Optional {
+			missing = append(missing, node)
+		}
+	}
+
+	return missing
+}
+
+// JSON renders the graph as JSON document with sorted nodes and edges.
+func (g *Graph) JSON() ([]byte, error) {
+	out := jsonGraph{
+		Playbooks: g.Playbooks,
+		Nodes:     g.SortedNodes(),
+		Edges:     make([]jsonEdge, 0),
+	}
+
+	for _, node := range out.Nodes {
+		for _, to := range g.sortedEdges(node.ID) {
+			out.Edges = append(out.Edges, jsonEdge{From: node.ID, To: to})
+		}
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func (g *Graph) DOT() string {
+	var b strings.Builder
+
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+
+	for _, node := range g.SortedNodes() {
+		attrs := fmt.Sprintf("label=%q, shape=%s", fmt.Sprintf("%s\n%s", node.Kind, node.ID), dotShape(node.Kind))
+
+		if node.Missing {
+			attrs += ", style=dashed"
+		}
+
+		if node.Missing && !node.Optional {
+			attrs += ", color=red"
+		}
+
+		fmt.Fprintf(&b, "  %q [%s];\n", node.ID, attrs)
+	}
+
+	for _, node := range g.SortedNodes() {
+		for _, to := range g.sortedEdges(node.ID) {
+			fmt.Fprintf(&b, "  %q -> %q;\n", node.ID, to)
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+func (g *Graph) sortedEdges(id string) []string {
+	edges := slices.Clone(g.Edges[id])
+	slices.Sort(edges)
+
+	return edges
+}
+
+func dotShape(kind Kind) string {
+	switch kind {
+	case KindPlaybook:
+		return "box"
+	case KindRole:
+		return "component"
+	case KindTasks:
+		return "note"
+	default:
+		return "ellipse"
+	}
+}
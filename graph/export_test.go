@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph() *Graph {
+	g := New()
+	g.Playbooks = []string{"site.yml"}
+
+	g.addNode("site.yml", KindPlaybook, false)
+	g.addNode("roles/nginx", KindRole, true)
+	g.addNode("vars/missing.yml", KindVars, false).Missing = true
+	g.addEdge("site.yml", "vars/missing.yml")
+	g.addEdge("site.yml", "roles/nginx")
+
+	return g
+}
+
+func TestJSON(t *testing.T) {
+	want := `{
+  "playbooks": [
+    "site.yml"
+  ],
+  "nodes": [
+    {
+      "id": "roles/nginx",
+      "kind": "role",
+      "dir": true
+    },
+    {
+      "id": "site.yml",
+      "kind": "playbook"
+    },
+    {
+      "id": "vars/missing.yml",
+      "kind": "vars",
+      "missing": true
+    }
+  ],
+  "edges": [
+    {
+      "from": "site.yml",
+      "to": "roles/nginx"
+    },
+    {
+      "from": "site.yml",
+      "to": "vars/missing.yml"
+    }
+  ]
+}`
+
+	got, err := testGraph().JSON()
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+}
+
+func TestDOT(t *testing.T) {
+	want := `digraph dependencies {
+  rankdir=LR;
+  "roles/nginx" [label="role\nroles/nginx", shape=component];
+  "site.yml" [label="playbook\nsite.yml", shape=box];
+  "vars/missing.yml" [label="vars\nvars/missing.yml", shape=ellipse, style=dashed, color=red];
+  "site.yml" -> "roles/nginx";
+  "site.yml" -> "vars/missing.yml";
+}
+`
+
+	assert.Equal(t, want, testGraph().DOT())
+}
+
+func TestMissing(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	content := `- hosts: all
+  roles:
+    - local
+    - geerlingguy.docker
+    - community.general.example
+    - unknown
+  tasks:
+    - include_tasks: tasks/missing.yml
+    - template:
+        src: "{{ item }}.j2"
+        dest: /tmp/
+`
+	require.NoError(t, os.MkdirAll(filepath.Join("roles", "local"), 0o755))
+	require.NoError(t, os.WriteFile("site.yml", []byte(content), 0o600))
+
+	g, err := Build([]string{"site.yml"}, Options{ExternalRoles: []string{"geerlingguy.docker"}})
+	require.NoError(t, err)
+
+	ids := make([]string, 0)
+	for _, node := range g.Missing() {
+		ids = append(ids, node.ID)
+	}
+
+	assert.Equal(t, []string{"tasks/missing.yml", "unknown"}, ids)
+	assert.Equal(t, []string{"site.yml"}, g.Referrers("unknown"))
+}
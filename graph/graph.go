@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Kind is the type of a node in the dependency graph.
+type Kind string
+
+const (
+	KindPlaybook Kind = "playbook"
+	KindRole     Kind = "role"
+	KindTasks    Kind = "tasks"
+	KindVars     Kind = "vars"
+	KindTemplate Kind = "template"
+	KindFile     Kind = "file"
+)
+
+// Node is a file or directory referenced by a playbook.
+type Node struct {
+	ID       string `json:"id"`
+	Kind     Kind   `json:"kind"`
+	Dir      bool   `json:"dir,omitempty"`
+	Missing  bool   `json:"missing,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Graph holds the dependencies of playbooks to roles, tasks, templates and vars files.
+type Graph struct {
+	Nodes     map[string]*Node
+	Edges     map[string][]string
+	Playbooks []string
+	// Global holds files and directories all playbooks depend on, e.g. inventories.
+	Global []string
+}
+
+// New creates an empty graph.
+func New() *Graph {
+	return &Graph{
+		Nodes: make(map[string]*Node),
+		Edges: make(map[string][]string),
+	}
+}
+
+// Affected returns the playbooks that depend on any of the given files in the
+// order of the playbooks.
+func (g *Graph) Affected(files []string) []string {
+	affected := make([]string, 0)
+
+	for _, file := range files {
+		if matchAny(filepath.Clean(file), g.Global) {
+			return slices.Clone(g.Playbooks)
+		}
+	}
+
+	for _, playbook := range g.Playbooks {
+		deps := g.Dependencies(playbook)
+
+		for _, file := range files {
+			if matchAny(filepath.Clean(file), deps) {
+				affected = append(affected, playbook)
+
+				break
+			}
+		}
+	}
+
+	return affected
+}
+
+// Dependencies returns the IDs of all nodes reachable from the given node, including
+// the node itself.
+func (g *Graph) Dependencies(id string) []string {
+	visited := map[string]bool{id: true}
+	deps := []string{id}
+
+	for i := 0; i < len(deps); i++ {
+		for _, dep := range g.Edges[deps[i]] {
+			if visited[dep] {
+				continue
+			}
+
+			visited[dep] = true
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps
+}
+
+// Referrers returns the IDs of the nodes referencing the given node.
+func (g *Graph) Referrers(id string) []string {
+	referrers := make([]string, 0)
+
+	for from, edges := range g.Edges {
+		if slices.Contains(edges, id) {
+			referrers = append(referrers, from)
+		}
+	}
+
+	slices.Sort(referrers)
+
+	return referrers
+}
+
+func (g *Graph) addNode(id string, kind Kind, dir bool) *Node {
+	if node, ok := g.Nodes[id]; ok {
+		return node
+	}
+
+	node := &Node{ID: id, Kind: kind, Dir: dir}
+	g.Nodes[id] = node
+
+	return node
+}
+
+func (g *Graph) addEdge(from, to string) {
+	if slices.Contains(g.Edges[from], to) {
+		return
+	}
+
+	g.Edges[from] = append(g.Edges[from], to)
+}
+
+// matchAny reports whether the file equals one of the paths or is located in one of them.
+func matchAny(file string, paths []string) bool {
+	for _, path := range paths {
+		if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestGraph(t *testing.T) *Graph {
+	t.Helper()
+	t.Chdir("testdata/project")
+
+	g, err := Build([]string{"site.yml", "web.yml", "db.yml"}, Options{
+		Inventories: []string{"inventory/hosts.yml"},
+		Global:      []string{"requirements.yml", ""},
+	})
+	require.NoError(t, err)
+
+	return g
+}
+
+func TestBuild(t *testing.T) {
+	g := buildTestGraph(t)
+
+	assert.ElementsMatch(t, []string{
+		"web.yml", "group_vars", "host_vars", "vars/web.yml", "roles/common", "roles/nginx",
+		"roles/certs", "templates/app.conf.j2", "tasks/deploy.yml", "tasks/restart.yml",
+	}, g.Dependencies("web.yml"))
+
+	assert.Equal(t, KindRole, g.Nodes["roles/nginx"].Kind)
+	assert.True(t, g.Nodes["roles/nginx"].Dir)
+	assert.Equal(t, KindTemplate, g.Nodes["templates/app.conf.j2"].Kind)
+	assert.Equal(t, KindVars, g.Nodes["vars/db.yml"].Kind)
+	assert.True(t, g.Nodes["host_vars"].Missing)
+	assert.False(t, g.Nodes["tasks/restart.yml"].Missing)
+	assert.Equal(t, []string{"inventory/hosts.yml", "inventory/group_vars", "inventory/host_vars", "requirements.yml"},
+		g.Global)
+}
+
+func TestAffected(t *testing.T) {
+	g := buildTestGraph(t)
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{name: "role dependency", files: []string{"roles/certs/tasks/main.yml"}, want: []string{"site.yml", "web.yml"}},
+		{name: "role", files: []string{"roles/postgres/tasks/main.yml"}, want: []string{"site.yml", "db.yml"}},
+		{name: "included vars", files: []string{"vars/db.yml"}, want: []string{"site.yml", "db.yml"}},
+		{name: "nested tasks", files: []string{"tasks/restart.yml"}, want: []string{"site.yml", "web.yml"}},
+		{name: "template", files: []string{"templates/app.conf.j2"}, want: []string{"site.yml", "web.yml"}},
+		{name: "playbook", files: []string{"db.yml"}, want: []string{"site.yml", "db.yml"}},
+		{name: "group vars", files: []string{"group_vars/all.yml"}, want: []string{"site.yml", "web.yml", "db.yml"}},
+		{name: "inventory", files: []string{"inventory/hosts.yml"}, want: []string{"site.yml", "web.yml", "db.yml"}},
+		{name: "global", files: []string{"requirements.yml"}, want: []string{"site.yml", "web.yml", "db.yml"}},
+		{name: "unrelated", files: []string{"README.md", "roles/common.md"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, g.Affected(tt.files))
+		})
+	}
+}
+
+func TestArgString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		key   string
+		want  string
+	}{
+		{name: "plain", value: "tasks.yml", key: "file", want: "tasks.yml"},
+		{name: "free-form", value: "src=app.j2 dest=/etc/app", key: "src", want: "app.j2"},
+		{name: "free-form without key", value: "dest=/etc/app", key: "src", want: ""},
+		{name: "dict", value: map[string]any{"name": "nginx"}, key: "name", want: "nginx"},
+		{name: "dict without key", value: map[string]any{"role": "nginx"}, key: "name", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, argString(tt.value, tt.key))
+		})
+	}
+}
+
+func TestArgBool(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  bool
+	}{
+		{name: "dict true", value: map[string]any{"remote_src": true}, want: true},
+		{name: "dict false", value: map[string]any{"remote_src": false}, want: false},
+		{name: "dict yes", value: map[string]any{"remote_src": "yes"}, want: true},
+		{name: "dict no", value: map[string]any{"remote_src": "no"}, want: false},
+		{name: "free-form true", value: "src=app.j2 dest=/etc/app remote_src=True", want: true},
+		{name: "free-form false", value: "src=app.j2 dest=/etc/app remote_src=false", want: false},
+		{name: "unset", value: map[string]any{"src": "app.j2"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, argBool(tt.value, "remote_src"))
+		})
+	}
+}
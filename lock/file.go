@@ -0,0 +1,239 @@
+package lock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// FileBackend stores locks as files in a directory, e.g. on a shared volume.
+type FileBackend struct {
+	Dir string
+}
+
+// TryAcquire creates the lock file. The file is written to a temporary file first and
+// linked to the lock path, which fails if the lock file exists, to make sure other
+// processes never see an incomplete lock file. Stale lock files are removed.
+func (b *FileBackend) TryAcquire(ctx context.Context, holder *Holder) (*Holder, error) {
+	if err := os.MkdirAll(b.Dir, 0o755); err != nil { //nolint:mnd
+		return nil, fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	tmp, err := b.writeTemp(holder)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp)
+
+	path := b.path(holder.Key)
+
+	err = os.Link(tmp, path)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	current, err := b.read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if current != nil && !current.IsStale(time.Now()) {
+		return current, nil
+	}
+
+	if current != nil {
+		log.Warn().Str("key", current.Key).Str("holder", current.Name).Str("pipeline", current.Pipeline).
+			Time("since", current.Acquired).Msg("remove stale lock")
+
+		if err := b.removeStale(path, current); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.TryAcquire(ctx, holder)
+}
+
+// removeStale removes the lock file of a stale holder. The file is moved aside first and
+// only removed if it still belongs to the stale holder; a lock file another process created
+// after the stale lock was read is moved back.
+func (b *FileBackend) removeStale(path string, stale *Holder) error {
+	moved, current, err := b.moveAside(path)
+	if err != nil || moved == "" {
+		return err
+	}
+	defer os.Remove(moved)
+
+	if current.ID == stale.ID && current.IsStale(time.Now()) {
+		return nil
+	}
+
+	return b.restore(moved, path, current)
+}
+
+// Refresh replaces the lock file with the refreshed holder if it is owned by the holder.
+// The lock file is moved aside while the owner is checked, so a lock another process
+// acquired in the meantime is never overwritten.
+func (b *FileBackend) Refresh(_ context.Context, holder *Holder) error {
+	path := b.path(holder.Key)
+
+	tmp, err := b.writeTemp(holder)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	moved, current, err := b.moveAside(path)
+	if err != nil {
+		return err
+	}
+
+	if moved == "" {
+		return fmt.Errorf("%w: %s", ErrLockLost, holder.Key)
+	}
+	defer os.Remove(moved)
+
+	if current.ID != holder.ID {
+		if err := b.restore(moved, path, current); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("%w: %s held by %s (%s)", ErrLockLost, holder.Key, current.Name, current.Pipeline)
+	}
+
+	err = os.Link(tmp, path)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrLockLost, holder.Key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	return nil
+}
+
+// Release removes the lock file if it is owned by the holder.
+func (b *FileBackend) Release(_ context.Context, holder *Holder) error {
+	path := b.path(holder.Key)
+
+	moved, current, err := b.moveAside(path)
+	if err != nil || moved == "" {
+		return err
+	}
+	defer os.Remove(moved)
+
+	if current.ID != holder.ID {
+		return b.restore(moved, path, current)
+	}
+
+	return nil
+}
+
+// moveAside moves the lock file to a unique path and returns the path and the holder of
+// the lock, or an empty path if the lock file does not exist. Other processes can neither
+// modify nor remove a lock file that was moved aside.
+func (b *FileBackend) moveAside(path string) (string, *Holder, error) {
+	id, err := newID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	moved := filepath.Join(b.Dir, fmt.Sprintf(".aside-%s", id))
+
+	if err := os.Rename(path, moved); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, nil
+		}
+
+		return "", nil, fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	current, err := b.read(moved)
+	if err != nil {
+		os.Remove(moved)
+
+		return "", nil, err
+	}
+
+	return moved, current, nil
+}
+
+// restore moves a lock file that was moved aside back to the lock path. If another process
+// acquired the lock in the meantime, the lock file is dropped.
+func (b *FileBackend) restore(moved, path string, holder *Holder) error {
+	err := os.Link(moved, path)
+	if errors.Is(err, fs.ErrExist) {
+		log.Warn().Str("key", holder.Key).Str("holder", holder.Name).Str("pipeline", holder.Pipeline).
+			Msg("lock taken over while moved aside")
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	return nil
+}
+
+// writeTemp writes the holder to a temporary file in the lock directory.
+func (b *FileBackend) writeTemp(holder *Holder) (string, error) {
+	content, err := json.Marshal(holder)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(b.Dir, ".lock-*")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	_, err = tmp.Write(content)
+	tmp.Close()
+
+	if err != nil {
+		os.Remove(tmp.Name())
+
+		return "", fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	return tmp.Name(), nil
+}
+
+func (b *FileBackend) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(b.Dir, fmt.Sprintf("%s.lock", hex.EncodeToString(sum[:])[:16]))
+}
+
+// read returns the holder of the lock file, or nil if the file does not exist.
+func (b *FileBackend) read(path string) (*Holder, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	holder := &Holder{}
+	if err := json.Unmarshal(content, holder); err != nil {
+		return nil, fmt.Errorf("%w: invalid lock file %s: %w", ErrLockBackend, path, err)
+	}
+
+	return holder, nil
+}
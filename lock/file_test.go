@@ -0,0 +1,256 @@
+package lock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBackend(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: filepath.Join(t.TempDir(), "locks")}
+
+	first, err := NewHolder("inventory/prod.yml", "alice", "https://ci.example.com/1")
+	require.NoError(t, err)
+
+	second, err := NewHolder("inventory/prod.yml", "bob", "https://ci.example.com/2")
+	require.NoError(t, err)
+
+	other, err := NewHolder("inventory/staging.yml", "bob", "https://ci.example.com/2")
+	require.NoError(t, err)
+
+	current, err := backend.TryAcquire(ctx, first)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	current, err = backend.TryAcquire(ctx, second)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, first.ID, current.ID)
+	assert.Equal(t, "alice", current.Name)
+
+	current, err = backend.TryAcquire(ctx, other)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	// Only the holder can release the lock.
+	require.NoError(t, backend.Release(ctx, second))
+	current, err = backend.TryAcquire(ctx, second)
+	require.NoError(t, err)
+	assert.NotNil(t, current)
+
+	require.NoError(t, backend.Release(ctx, first))
+	current, err = backend.TryAcquire(ctx, second)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	entries, err := os.ReadDir(backend.Dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestFileBackendStale(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: t.TempDir()}
+
+	stale := &Holder{ID: "stale", Key: "prod", Acquired: time.Now().Add(-2 * time.Hour), TTL: Duration(time.Hour)}
+	current, err := backend.TryAcquire(ctx, stale)
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	holder := &Holder{ID: "new", Key: "prod", Acquired: time.Now(), TTL: Duration(time.Hour)}
+	current, err = backend.TryAcquire(ctx, holder)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+}
+
+func TestFileBackendRemoveStale(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: t.TempDir()}
+
+	stale := &Holder{ID: "stale", Key: "prod", Acquired: time.Now().Add(-2 * time.Hour), TTL: Duration(time.Hour)}
+	current, err := backend.TryAcquire(ctx, stale)
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	// Another process removed the stale lock and acquired it after it was read.
+	path := backend.path("prod")
+	require.NoError(t, os.Remove(path))
+
+	holder := &Holder{ID: "new", Key: "prod", Acquired: time.Now(), TTL: Duration(time.Hour)}
+	current, err = backend.TryAcquire(ctx, holder)
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	require.NoError(t, backend.removeStale(path, stale))
+
+	current, err = backend.read(path)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "new", current.ID)
+
+	entries, err := os.ReadDir(backend.Dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileBackendStaleConcurrent(t *testing.T) {
+	ctx := context.Background()
+
+	for range 50 {
+		dir := t.TempDir()
+
+		stale := &Holder{ID: "stale", Key: "prod", Acquired: time.Now().Add(-2 * time.Hour), TTL: Duration(time.Hour)}
+		current, err := (&FileBackend{Dir: dir}).TryAcquire(ctx, stale)
+		require.NoError(t, err)
+		require.Nil(t, current)
+
+		var (
+			wg       sync.WaitGroup
+			acquired atomic.Int32
+		)
+
+		for _, id := range []string{"first", "second", "third", "fourth"} {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				holder := &Holder{ID: id, Key: "prod", Acquired: time.Now(), TTL: Duration(time.Hour)}
+
+				current, err := (&FileBackend{Dir: dir}).TryAcquire(ctx, holder)
+				assert.NoError(t, err)
+
+				if current == nil {
+					acquired.Add(1)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		assert.Equal(t, int32(1), acquired.Load())
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+}
+
+func TestFileBackendRefresh(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: t.TempDir()}
+
+	holder := &Holder{ID: "first", Key: "prod", Acquired: time.Now().Add(-2 * time.Hour), TTL: Duration(time.Hour)}
+	current, err := backend.TryAcquire(ctx, holder)
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	holder.Refreshed = time.Now()
+	require.NoError(t, backend.Refresh(ctx, holder))
+
+	other := &Holder{ID: "second", Key: "prod", Acquired: time.Now(), TTL: Duration(time.Hour)}
+	current, err = backend.TryAcquire(ctx, other)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "first", current.ID)
+
+	assert.ErrorIs(t, backend.Refresh(ctx, other), ErrLockLost)
+
+	current, err = backend.read(backend.path("prod"))
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "first", current.ID)
+
+	entries, err := os.ReadDir(backend.Dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestKeepAlive(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: t.TempDir()}
+	ttl := 100 * time.Millisecond
+
+	first, err := NewHolder("prod", "alice", "")
+	require.NoError(t, err)
+	require.NoError(t, Acquire(ctx, backend, first, Options{Timeout: time.Second, TTL: ttl}))
+
+	lost, stop := KeepAlive(backend, first)
+
+	second, err := NewHolder("prod", "bob", "")
+	require.NoError(t, err)
+
+	opts := Options{Timeout: 3 * ttl, TTL: ttl, Interval: 10 * time.Millisecond}
+	assert.ErrorIs(t, Acquire(ctx, backend, second, opts), ErrLockTimeout)
+	assert.NoError(t, lost.Err())
+
+	stop()
+
+	assert.ErrorIs(t, context.Cause(lost), context.Canceled)
+	assert.NoError(t, Acquire(ctx, backend, second, opts))
+}
+
+func TestKeepAliveLost(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: t.TempDir()}
+	ttl := 100 * time.Millisecond
+
+	first, err := NewHolder("prod", "alice", "")
+	require.NoError(t, err)
+	require.NoError(t, Acquire(ctx, backend, first, Options{Timeout: time.Second, TTL: ttl}))
+
+	lost, stop := KeepAlive(backend, first)
+	defer stop()
+
+	// Another process took over the lock, e.g. after the refresh was delayed.
+	require.NoError(t, os.Remove(backend.path("prod")))
+
+	second, err := NewHolder("prod", "bob", "")
+	require.NoError(t, err)
+	require.NoError(t, Acquire(ctx, backend, second, Options{Timeout: time.Second, TTL: time.Hour}))
+
+	select {
+	case <-lost.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lost lock not detected")
+	}
+
+	assert.ErrorIs(t, context.Cause(lost), ErrLockLost)
+
+	current, err := backend.read(backend.path("prod"))
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, second.ID, current.ID)
+}
+
+func TestAcquire(t *testing.T) {
+	ctx := context.Background()
+	backend := &FileBackend{Dir: t.TempDir()}
+
+	first, err := NewHolder("prod", "alice", "")
+	require.NoError(t, err)
+	require.NoError(t, Acquire(ctx, backend, first, Options{Timeout: time.Second, TTL: time.Hour}))
+
+	second, err := NewHolder("prod", "bob", "")
+	require.NoError(t, err)
+
+	opts := Options{Timeout: 50 * time.Millisecond, TTL: time.Hour, Interval: 10 * time.Millisecond}
+	assert.ErrorIs(t, Acquire(ctx, backend, second, opts), ErrLockTimeout)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+
+		_ = backend.Release(ctx, first)
+	}()
+
+	opts.Timeout = 5 * time.Second
+	assert.NoError(t, Acquire(ctx, backend, second, opts))
+}
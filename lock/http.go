@@ -0,0 +1,115 @@
+package lock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPBackend acquires locks from an HTTP endpoint. A lock is acquired by a POST request
+// with the holder as JSON body to `<url>/<key>`, which responds with 200 or 201 if the
+// lock was acquired and with 409 or 423 and the current holder as JSON body if the lock
+// is held. A held lock is refreshed by a PUT request with the holder as JSON body, which
+// responds with 404, 409 or 423 if the lock is not held by the holder anymore. Expiring
+// stale locks by the TTL and refresh time of the holder is up to the endpoint. A lock is
+// released by a DELETE request with the holder as JSON body.
+type HTTPBackend struct {
+	URL    string
+	Client *http.Client
+}
+
+// TryAcquire requests the lock from the endpoint.
+func (b *HTTPBackend) TryAcquire(ctx context.Context, holder *Holder) (*Holder, error) {
+	resp, err := b.do(ctx, http.MethodPost, holder)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil, nil
+	case http.StatusConflict, http.StatusLocked:
+		current := &Holder{}
+		if err := json.NewDecoder(resp.Body).Decode(current); err != nil {
+			return nil, fmt.Errorf("%w: invalid lock holder: %w", ErrLockBackend, err)
+		}
+
+		return current, nil
+	default:
+		return nil, statusError(resp)
+	}
+}
+
+// Refresh refreshes the lock at the endpoint.
+func (b *HTTPBackend) Refresh(ctx context.Context, holder *Holder) error {
+	resp, err := b.do(ctx, http.MethodPut, holder)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound, http.StatusConflict, http.StatusLocked:
+		return fmt.Errorf("%w: %s", ErrLockLost, holder.Key)
+	default:
+		return statusError(resp)
+	}
+}
+
+// Release releases the lock at the endpoint.
+func (b *HTTPBackend) Release(ctx context.Context, holder *Holder) error {
+	resp, err := b.do(ctx, http.MethodDelete, holder)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return statusError(resp)
+	}
+}
+
+func (b *HTTPBackend) do(ctx context.Context, method string, holder *Holder) (*http.Response, error) {
+	body, err := json.Marshal(holder)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s", strings.TrimSuffix(b.URL, "/"), url.PathEscape(holder.Key))
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLockBackend, err)
+	}
+
+	return resp, nil
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:mnd
+
+	return fmt.Errorf("%w: unexpected status %s: %s", ErrLockBackend, resp.Status, strings.TrimSpace(string(msg)))
+}
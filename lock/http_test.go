@@ -0,0 +1,119 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLockServer creates a stand-in for an HTTP lock endpoint.
+func newLockServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var mu sync.Mutex
+
+	locks := make(map[string]*Holder)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		holder := &Holder{}
+		if err := json.NewDecoder(r.Body).Decode(holder); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		key := r.URL.Path[1:]
+		current := locks[key]
+
+		switch r.Method {
+		case http.MethodPost:
+			if current != nil && current.ID != holder.ID && !current.IsStale(time.Now()) {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(current)
+
+				return
+			}
+
+			locks[key] = holder
+
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPut:
+			if current == nil || current.ID != holder.ID {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			locks[key] = holder
+
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			if current == nil || current.ID != holder.ID {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			delete(locks, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestHTTPBackend(t *testing.T) {
+	ctx := context.Background()
+	backend := &HTTPBackend{URL: newLockServer(t).URL + "/"}
+
+	first, err := NewHolder("inventories/prod.yml|web", "alice", "https://ci.example.com/1")
+	require.NoError(t, err)
+
+	second, err := NewHolder("inventories/prod.yml|web", "bob", "https://ci.example.com/2")
+	require.NoError(t, err)
+
+	current, err := backend.TryAcquire(ctx, first)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	current, err = backend.TryAcquire(ctx, second)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "alice", current.Name)
+	assert.Equal(t, "https://ci.example.com/1", current.Pipeline)
+
+	require.NoError(t, backend.Refresh(ctx, first))
+	assert.ErrorIs(t, backend.Refresh(ctx, second), ErrLockLost)
+
+	require.NoError(t, backend.Release(ctx, second))
+	require.NoError(t, backend.Release(ctx, first))
+
+	current, err = backend.TryAcquire(ctx, second)
+	require.NoError(t, err)
+	assert.Nil(t, current)
+}
+
+func TestHTTPBackendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	backend := &HTTPBackend{URL: server.URL}
+
+	_, err := backend.TryAcquire(context.Background(), &Holder{Key: "prod"})
+	assert.ErrorIs(t, err, ErrLockBackend)
+	assert.ErrorContains(t, err, "unavailable")
+}
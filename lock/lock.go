@@ -0,0 +1,204 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultInterval = 5 * time.Second
+
+	// refreshesPerTTL is the number of times a held lock is refreshed within its TTL.
+	refreshesPerTTL = 3
+)
+
+var (
+	ErrLockTimeout = errors.New("timeout waiting for lock")
+	ErrLockBackend = errors.New("lock backend error")
+	ErrLockLost    = errors.New("lock lost")
+)
+
+// Holder describes the owner of a lock.
+type Holder struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Name      string    `json:"name"`
+	Pipeline  string    `json:"pipeline"`
+	Acquired  time.Time `json:"acquired"`
+	Refreshed time.Time `json:"refreshed,omitzero"`
+	TTL       Duration  `json:"ttl"`
+}
+
+// Backend stores locks.
+type Backend interface {
+	// TryAcquire attempts to acquire the lock once. If the lock is held by someone
+	// else, the current holder is returned.
+	TryAcquire(ctx context.Context, holder *Holder) (*Holder, error)
+	// Refresh updates the refresh time of the lock if it is held by the holder and
+	// returns ErrLockLost otherwise.
+	Refresh(ctx context.Context, holder *Holder) error
+	// Release releases the lock if it is held by the holder.
+	Release(ctx context.Context, holder *Holder) error
+}
+
+// Options configure how a lock is acquired.
+type Options struct {
+	// Timeout is the maximum time to wait for the lock.
+	Timeout time.Duration
+	// TTL is the time after which a lock is considered stale.
+	TTL time.Duration
+	// Interval is the time between attempts to acquire the lock.
+	Interval time.Duration
+}
+
+// NewHolder creates a holder with a random ID.
+func NewHolder(key, name, pipeline string) (*Holder, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Holder{ID: id, Key: key, Name: name, Pipeline: pipeline}, nil
+}
+
+func newID() (string, error) {
+	id := make([]byte, 8) //nolint:mnd
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// Acquire waits until the lock is acquired or the timeout is exceeded.
+func Acquire(ctx context.Context, backend Backend, holder *Holder, opts Options) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	holder.TTL = Duration(opts.TTL)
+
+	for {
+		holder.Acquired = time.Now().UTC()
+
+		current, err := backend.TryAcquire(ctx, holder)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: %s: %w", ErrLockTimeout, holder.Key, err)
+			}
+
+			return err
+		}
+
+		if current == nil {
+			log.Info().Str("key", holder.Key).Str("id", holder.ID).Msg("lock acquired")
+
+			return nil
+		}
+
+		log.Info().Str("key", holder.Key).Str("holder", current.Name).Str("pipeline", current.Pipeline).
+			Time("since", current.Acquired).Msg("lock held, waiting")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s held by %s (%s) since %s", ErrLockTimeout, holder.Key, current.Name,
+				current.Pipeline, current.Acquired.Format(time.RFC3339))
+		case <-time.After(interval):
+		}
+	}
+}
+
+// KeepAlive refreshes the lock of the holder until the returned function is called, so a
+// lock held longer than its TTL is not considered stale. The returned context is canceled
+// with ErrLockLost as cause if the lock was taken over by someone else.
+func KeepAlive(backend Backend, holder *Holder) (context.Context, func()) {
+	lost, lose := context.WithCancelCause(context.Background())
+
+	if holder.TTL <= 0 {
+		return lost, func() { lose(nil) }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(time.Duration(holder.TTL) / refreshesPerTTL)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			holder.Refreshed = time.Now().UTC()
+
+			err := backend.Refresh(ctx, holder)
+			if err == nil || ctx.Err() != nil {
+				continue
+			}
+
+			if errors.Is(err, ErrLockLost) {
+				log.Error().Err(err).Str("key", holder.Key).Msg("lock lost")
+				lose(err)
+
+				return
+			}
+
+			log.Warn().Err(err).Str("key", holder.Key).Msg("failed to refresh lock")
+		}
+	}()
+
+	return lost, func() {
+		cancel()
+		<-done
+		lose(nil)
+	}
+}
+
+// IsStale reports whether the lock of the holder has expired.
+func (h *Holder) IsStale(now time.Time) bool {
+	last := h.Acquired
+	if h.Refreshed.After(last) {
+		last = h.Refreshed
+	}
+
+	return h.TTL > 0 && now.Sub(last) > time.Duration(h.TTL)
+}
+
+// Duration is a time.Duration encoded as string in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(v)
+
+	return nil
+}
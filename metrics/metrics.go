@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const TypeGauge = "gauge"
+
+// Label is a name value pair identifying a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metric is a metric family with its samples.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []*Sample
+}
+
+// Sample is a value of a metric with its labels.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Set is a set of metrics rendered in the Prometheus text format.
+type Set struct {
+	Metrics []*Metric
+}
+
+// Add adds a sample to the metric. The values of samples with equal labels are summed.
+func (s *Set) Add(name, help, typ string, value float64, labels ...Label) {
+	m := s.metric(name, help, typ)
+
+	for _, sample := range m.Samples {
+		if slices.Equal(sample.Labels, labels) {
+			sample.Value += value
+
+			return
+		}
+	}
+
+	m.Samples = append(m.Samples, &Sample{Labels: labels, Value: value})
+}
+
+// Gauge adds a gauge sample.
+func (s *Set) Gauge(name, help string, value float64, labels ...Label) {
+	s.Add(name, help, TypeGauge, value, labels...)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (s *Set) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	for _, m := range s.Metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.Name, escape(m.Help, false))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", m.Name, m.Type)
+
+		for _, sample := range m.Samples {
+			b.WriteString(m.Name)
+
+			if len(sample.Labels) > 0 {
+				pairs := make([]string, 0, len(sample.Labels))
+				for _, l := range sample.Labels {
+					pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l.Name, escape(l.Value, true)))
+				}
+
+				fmt.Fprintf(&b, "{%s}", strings.Join(pairs, ","))
+			}
+
+			fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(sample.Value, 'g', -1, 64))
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+func (s *Set) metric(name, help, typ string) *Metric {
+	for _, m := range s.Metrics {
+		if m.Name == name {
+			return m
+		}
+	}
+
+	m := &Metric{Name: name, Help: help, Type: typ}
+	s.Metrics = append(s.Metrics, m)
+
+	return m
+}
+
+func escape(s string, quote bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+
+	if quote {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+
+	return s
+}
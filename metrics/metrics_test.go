@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetWriteTo(t *testing.T) {
+	set := &Set{}
+	set.Gauge("ansible_run_duration_seconds", "Duration of the run.", 12.5)
+	set.Gauge("ansible_task_results", "Task results by status.", 3,
+		Label{Name: "playbook", Value: "site.yml"}, Label{Name: "status", Value: "ok"})
+	set.Gauge("ansible_task_results", "Task results by status.", 2,
+		Label{Name: "playbook", Value: "site.yml"}, Label{Name: "status", Value: "ok"})
+	set.Gauge("ansible_task_results", "Task results by status.", 1,
+		Label{Name: "playbook", Value: `say "hi"\n`}, Label{Name: "status", Value: "changed"})
+
+	var b strings.Builder
+
+	_, err := set.WriteTo(&b)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# HELP ansible_run_duration_seconds Duration of the run.
+# TYPE ansible_run_duration_seconds gauge
+ansible_run_duration_seconds 12.5
+# HELP ansible_task_results Task results by status.
+# TYPE ansible_task_results gauge
+ansible_task_results{playbook="site.yml",status="ok"} 5
+ansible_task_results{playbook="say \"hi\"\\n",status="changed"} 1
+`, b.String())
+}
+
+func TestPush(t *testing.T) {
+	var (
+		method, path, contentType string
+		body                      []byte
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	set := &Set{}
+	set.Gauge("ansible_run_success", "Whether the run succeeded.", 1)
+
+	pusher := &Pusher{
+		URL: server.URL + "/",
+		Job: "wp-ansible",
+		Grouping: []Label{
+			{Name: "repo", Value: "infra/deploy"},
+			{Name: "environment", Value: "production"},
+			{Name: "branch", Value: ""},
+		},
+	}
+	require.NoError(t, pusher.Push(t.Context(), set))
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/wp-ansible/repo@base64/aW5mcmEvZGVwbG95/environment/production/branch@base64/=", path)
+	assert.Equal(t, "text/plain; version=0.0.4", contentType)
+	assert.Contains(t, string(body), "ansible_run_success 1\n")
+}
+
+func TestPusherEndpoint(t *testing.T) {
+	tests := []struct {
+		name      string
+		inventory string
+		want      string
+	}{
+		{
+			name:      "production",
+			inventory: "inventories/prod.yml",
+			want:      "http://pushgateway:9091/metrics/job/wp-ansible/inventory@base64/aW52ZW50b3JpZXMvcHJvZC55bWw",
+		},
+		{
+			name:      "staging",
+			inventory: "staging",
+			want:      "http://pushgateway:9091/metrics/job/wp-ansible/inventory/staging",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pusher := &Pusher{
+				URL:      "http://pushgateway:9091",
+				Job:      "wp-ansible",
+				Grouping: []Label{{Name: "inventory", Value: tt.inventory}},
+			}
+
+			assert.Equal(t, tt.want, pusher.endpoint())
+		})
+	}
+}
+
+func TestPushError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := (&Pusher{URL: server.URL, Job: "wp-ansible"}).Push(t.Context(), &Set{})
+	assert.ErrorIs(t, err, ErrPushFailed)
+}
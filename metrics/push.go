@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const contentType = "text/plain; version=0.0.4"
+
+var ErrPushFailed = errors.New("metrics push failed")
+
+// Pusher pushes metrics to a Prometheus Pushgateway. A push replaces all metrics of
+// the group identified by the job and the grouping labels.
+type Pusher struct {
+	URL      string
+	Job      string
+	Grouping []Label
+	Client   *http.Client
+}
+
+// Push sends the metrics to the Pushgateway.
+func (p *Pusher) Push(ctx context.Context, set *Set) error {
+	var body bytes.Buffer
+	if _, err := set.WriteTo(&body); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.endpoint(), &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrPushFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:mnd
+
+		return fmt.Errorf("%w: unexpected status %s: %s", ErrPushFailed, resp.Status,
+			strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// endpoint returns the URL of the group. Empty values and values containing a slash
+// are base64 encoded as supported by the Pushgateway.
+func (p *Pusher) endpoint() string {
+	var b strings.Builder
+
+	b.WriteString(strings.TrimSuffix(p.URL, "/"))
+	b.WriteString("/metrics")
+
+	for _, l := range append([]Label{{Name: "job", Value: p.Job}}, p.Grouping...) {
+		switch {
+		case l.Value == "":
+			fmt.Fprintf(&b, "/%s@base64/=", l.Name)
+		case strings.Contains(l.Value, "/"):
+			fmt.Fprintf(&b, "/%s@base64/%s", l.Name, base64.RawURLEncoding.EncodeToString([]byte(l.Value)))
+		default:
+			fmt.Fprintf(&b, "/%s/%s", l.Name, url.PathEscape(l.Value))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/approval"
+)
+
+var ErrApprovalCheckFailed = errors.New("check run for approval failed")
+
+// approvalSummaryLimit is the maximum size of the check output sent for approval.
+const approvalSummaryLimit = 256 * 1024
+
+// needsApproval reports whether the runs have to be approved. Runs that are all in check
+// mode, e.g. in pull request safe mode, do not change anything and need no approval.
+func (p *Plugin) needsApproval(groups []runGroup) bool {
+	if p.Settings.ApprovalURL == "" {
+		return false
+	}
+
+	for _, group := range groups {
+		for _, run := range group {
+			if !run.ansible.Check {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// requestApproval runs the playbooks in check and diff mode and waits until the
+// resulting changes are approved.
+func (p *Plugin) requestApproval(groups []runGroup) error {
+	checks := make([]runGroup, 0, len(groups))
+	runs := make([]*playRun, 0)
+
+	for _, group := range groups {
+		check := make(runGroup, 0, len(group))
+
+		for _, run := range group {
+			a := run.ansible
+			a.Check = true
+			a.Diff = true
+
+			check = append(check, &playRun{name: run.name, ansible: a, output: &bytes.Buffer{}})
+		}
+
+		checks = append(checks, check)
+		runs = append(runs, check...)
+	}
+
+	log.Info().Msg("run playbooks in check mode for approval")
+
+	if err := p.runPlays(checks); err != nil {
+		return fmt.Errorf("%w: %w", ErrApprovalCheckFailed, err)
+	}
+
+	req, err := approval.NewRequest()
+	if err != nil {
+		return err
+	}
+
+	req.Inventories = p.Settings.Ansible.Inventories
+	req.Playbooks = p.Settings.Ansible.Playbooks
+	req.Summary = approvalSummary(runs)
+
+	if m := p.Settings.metadata; m != nil {
+		req.Commit, req.Branch, req.Author, req.Pipeline = m.Commit, m.Branch, m.Author, m.PipelineURL
+	}
+
+	client := &approval.Client{URL: p.Settings.ApprovalURL, Token: p.Settings.ApprovalToken}
+	opts := approval.Options{Timeout: p.Settings.ApprovalTimeout}
+
+	_, err = approval.Wait(context.Background(), client, req, opts)
+
+	return err
+}
+
+// approvalSummary returns the output of the check runs without color codes. If the
+// output exceeds the size limit, the beginning is cut to keep the recap.
+func approvalSummary(runs []*playRun) string {
+	var b strings.Builder
+
+	for _, run := range runs {
+		if run.name != "" {
+			fmt.Fprintf(&b, "### %s\n", run.name)
+		}
+
+		b.WriteString(ansible.StripANSI(run.output.String()))
+	}
+
+	summary := b.String()
+	if len(summary) > approvalSummaryLimit {
+		summary = "[...]\n" + summary[len(summary)-approvalSummaryLimit:]
+	}
+
+	return summary
+}
@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/approval"
+)
+
+func TestRequestApproval(t *testing.T) {
+	binDir := fakeAnsibleBin(t)
+
+	tests := []struct {
+		name        string
+		inventories []string
+		decision    approval.Status
+		wantErr     error
+	}{
+		{name: "approved", inventories: []string{"eu", "us"}, decision: approval.StatusApproved},
+		{name: "denied", inventories: []string{"eu"}, decision: approval.StatusDenied, wantErr: approval.ErrApprovalDenied},
+		{name: "check failed", inventories: []string{"fail"}, wantErr: ErrApprovalCheckFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *approval.Request
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = &approval.Request{}
+				_ = json.NewDecoder(r.Body).Decode(req)
+				_ = json.NewEncoder(w).Encode(&approval.Decision{Status: tt.decision})
+			}))
+			defer server.Close()
+
+			p := &Plugin{Settings: &Settings{
+				InventoryMatrix: true,
+				ApprovalURL:     server.URL,
+				ApprovalTimeout: time.Second,
+				metadata:        &metadata{Commit: "a1b2c3", Author: "octocat"},
+				Ansible: ansible.Ansible{
+					BinDir:      binDir,
+					Inventories: tt.inventories,
+					Playbooks:   []string{"site.yml"},
+				},
+			}}
+
+			groups := p.planRuns()
+
+			err := p.requestApproval(groups)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, req)
+			assert.Equal(t, "a1b2c3", req.Commit)
+			assert.Contains(t, req.Summary, "### eu\n")
+			assert.Contains(t, req.Summary, "--inventory us --check --diff")
+
+			for _, run := range groups[0] {
+				assert.False(t, run.ansible.Check)
+			}
+		})
+	}
+}
+
+func TestNeedsApproval(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *Settings
+		want     bool
+	}{
+		{
+			name:     "without approval url",
+			settings: &Settings{Ansible: ansible.Ansible{Playbooks: []string{"site.yml"}}},
+			want:     false,
+		},
+		{
+			name: "apply",
+			settings: &Settings{
+				ApprovalURL: "https://approval.example.com",
+				Ansible:     ansible.Ansible{Playbooks: []string{"site.yml"}},
+			},
+			want: true,
+		},
+		{
+			name: "check mode",
+			settings: &Settings{
+				ApprovalURL: "https://approval.example.com",
+				Ansible:     ansible.Ansible{Playbooks: []string{"site.yml"}, Check: true},
+			},
+			want: false,
+		},
+		{
+			name: "playbook override applies changes",
+			settings: &Settings{
+				ApprovalURL:       "https://approval.example.com",
+				PerPlaybook:       true,
+				PlaybookOverrides: playbookOverrides{{Pattern: "deploy.yml", Check: new(bool)}},
+				Ansible:           ansible.Ansible{Playbooks: []string{"site.yml", "deploy.yml"}, Check: true},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: tt.settings}
+
+			assert.Equal(t, tt.want, p.needsApproval(p.planRuns()))
+		})
+	}
+}
+
+func TestApprovalSummary(t *testing.T) {
+	runs := []*playRun{
+		{output: bytes.NewBufferString("\x1b[0;33mchanged: [web1]\x1b[0m\n")},
+	}
+
+	assert.Equal(t, "changed: [web1]\n", approvalSummary(runs))
+
+	runs[0].output = bytes.NewBufferString(strings.Repeat("x", approvalSummaryLimit) + "PLAY RECAP\n")
+	summary := approvalSummary(runs)
+
+	assert.True(t, strings.HasPrefix(summary, "[...]\n"))
+	assert.True(t, strings.HasSuffix(summary, "PLAY RECAP\n"))
+	assert.Len(t, summary, approvalSummaryLimit+len("[...]\n"))
+}
@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const cacheMarker = ".complete"
+
+// CacheEntry is a directory of the dependency cache keyed by the content hash
+// of the requirements files it was created from.
+type CacheEntry struct {
+	Path string
+	Hit  bool
+}
+
+// NewCacheEntry returns the cache entry of the given kind for the given requirements files.
+// The entry is considered a hit if it was completely populated by a previous run.
+func NewCacheEntry(dir, kind string, extra []string, files ...string) (*CacheEntry, error) {
+	key, err := cacheKey(extra, files...)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &CacheEntry{
+		Path: filepath.Join(dir, kind, key),
+	}
+
+	_, err = os.Stat(filepath.Join(entry.Path, cacheMarker))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	entry.Hit = err == nil
+
+	return entry, nil
+}
+
+// Save marks the cache entry as completely populated.
+func (e *CacheEntry) Save() error {
+	if err := os.MkdirAll(e.Path, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(e.Path, cacheMarker), nil, 0o600)
+}
+
+func cacheKey(extra []string, files ...string) (string, error) {
+	hash := sha256.New()
+
+	for _, s := range extra {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
+	}
+
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.Copy(hash, f)
+		f.Close()
+
+		if err != nil {
+			return "", err
+		}
+
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,45 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheEntry(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	req := filepath.Join(dir, "requirements.txt")
+
+	require.NoError(t, os.WriteFile(req, []byte("requests==2.32.3\n"), 0o600))
+
+	entry, err := NewCacheEntry(cacheDir, "pip", []string{"amd64"}, req)
+	require.NoError(t, err)
+	assert.False(t, entry.Hit)
+	assert.Equal(t, filepath.Join(cacheDir, "pip"), filepath.Dir(entry.Path))
+
+	require.NoError(t, entry.Save())
+
+	cached, err := NewCacheEntry(cacheDir, "pip", []string{"amd64"}, req)
+	require.NoError(t, err)
+	assert.True(t, cached.Hit)
+	assert.Equal(t, entry.Path, cached.Path)
+
+	other, err := NewCacheEntry(cacheDir, "pip", []string{"arm64"}, req)
+	require.NoError(t, err)
+	assert.False(t, other.Hit)
+	assert.NotEqual(t, entry.Path, other.Path)
+
+	require.NoError(t, os.WriteFile(req, []byte("requests==2.32.4\n"), 0o600))
+
+	changed, err := NewCacheEntry(cacheDir, "pip", []string{"amd64"}, req)
+	require.NoError(t, err)
+	assert.False(t, changed.Hit)
+	assert.NotEqual(t, entry.Path, changed.Path)
+
+	_, err = NewCacheEntry(cacheDir, "pip", nil, filepath.Join(dir, "missing.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
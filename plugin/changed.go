@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/graph"
+	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
+)
+
+const gitBin = "git"
+
+// selectChanged reduces the playbooks to those depending on files changed by the commit
+// or pull request. If the changed files can not be determined, all playbooks are run.
+func (p *Plugin) selectChanged(g *graph.Graph) error {
+	base := p.diffBase()
+
+	files, err := p.changedFiles(base)
+	if err != nil {
+		log.Warn().Err(err).Str("base", base).Msg("failed to detect changed files, run all playbooks")
+
+		return nil
+	}
+
+	affected := g.Affected(files)
+
+	log.Info().Str("base", base).Strs("changed", files).Strs("playbooks", affected).Msg("playbooks affected by changes")
+
+	if len(affected) == 0 {
+		p.Settings.skipRun = true
+
+		return nil
+	}
+
+	p.Settings.Ansible.Playbooks = affected
+
+	return nil
+}
+
+// diffBase returns the git revision the changes are compared to. If not configured, the
+// target branch is used for pull requests and the previous commit otherwise.
+func (p *Plugin) diffBase() string {
+	meta := p.Settings.metadata
+
+	switch {
+	case p.Settings.ChangedOnlyBase != "":
+		return p.Settings.ChangedOnlyBase
+	case strings.HasPrefix(meta.Event, "pull_request") && meta.TargetBranch != "":
+		return fmt.Sprintf("origin/%s", meta.TargetBranch)
+	case meta.PrevCommit != "":
+		return meta.PrevCommit
+	default:
+		return "HEAD~1"
+	}
+}
+
+// changedFiles returns the files changed since the merge base of base and HEAD,
+// relative to the working directory. The names are NUL separated to keep spaces and
+// non-ASCII characters unquoted.
+func (p *Plugin) changedFiles(base string) ([]string, error) {
+	var out bytes.Buffer
+
+	cmd := plugin_exec.Command(gitBin, "diff", "-z", "--name-only", "--relative", fmt.Sprintf("%s...HEAD", base))
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0)
+
+	for _, name := range strings.Split(out.String(), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestDiffBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		metadata *metadata
+		want     string
+	}{
+		{name: "configured", base: "v1.0.0", metadata: &metadata{PrevCommit: "a1b2c3"}, want: "v1.0.0"},
+		{
+			name:     "pull request",
+			metadata: &metadata{Event: "pull_request", TargetBranch: "main", PrevCommit: "a1b2c3"},
+			want:     "origin/main",
+		},
+		{name: "push", metadata: &metadata{Event: "push", PrevCommit: "a1b2c3"}, want: "a1b2c3"},
+		{name: "fallback", metadata: &metadata{Event: "manual"}, want: "HEAD~1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{ChangedOnlyBase: tt.base, metadata: tt.metadata}}
+
+			assert.Equal(t, tt.want, p.diffBase())
+		})
+	}
+}
+
+func TestSelectChanged(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+	}
+
+	write("web.yml", "- hosts: web\n  vars_files:\n    - vars/web settings.yml\n    - vars/día.yml\n"+
+		"  roles:\n    - nginx\n")
+	write("vars/web settings.yml", "port: 80\n")
+	write("vars/día.yml", "day: 1\n")
+	write("db.yml", "- hosts: db\n  roles:\n    - postgres\n")
+	write("roles/nginx/tasks/main.yml", "- debug: msg=nginx\n")
+	write("roles/postgres/tasks/main.yml", "- debug: msg=postgres\n")
+	write("hosts.yml", "all:\n")
+
+	git("init", "--quiet")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "initial")
+
+	tests := []struct {
+		name     string
+		change   string
+		want     []string
+		wantSkip bool
+	}{
+		{name: "role changed", change: "roles/postgres/tasks/main.yml", want: []string{"db.yml"}},
+		{name: "file name with space changed", change: "vars/web settings.yml", want: []string{"web.yml"}},
+		{name: "non-ascii file name changed", change: "vars/día.yml", want: []string{"web.yml"}},
+		{name: "inventory changed", change: "hosts.yml", want: []string{"web.yml", "db.yml"}},
+		{name: "unrelated change", change: "README.md", wantSkip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(tt.change, fmt.Sprintf("# %s\n", tt.name))
+			git("add", "-A")
+			git("commit", "--quiet", "-m", tt.name)
+
+			p := &Plugin{Settings: &Settings{
+				Ansible:  ansible.Ansible{Inventories: []string{"hosts.yml"}, Playbooks: []string{"web.yml", "db.yml"}},
+				metadata: &metadata{},
+			}}
+
+			g, err := p.buildGraph()
+			require.NoError(t, err)
+
+			require.NoError(t, p.selectChanged(g))
+			assert.Equal(t, tt.wantSkip, p.Settings.skipRun)
+
+			if !tt.wantSkip {
+				assert.Equal(t, tt.want, p.Settings.Ansible.Playbooks)
+			}
+		})
+	}
+}
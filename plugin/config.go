@@ -0,0 +1,106 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+const ansibleConfigFile = "ansible.cfg"
+
+// writeAnsibleConfig renders the configured options into a temporary ansible.cfg and returns its path.
+// If enabled, the options are merged into the existing config. The file is written to the temporary
+// directory to keep it out of the workspace, relative paths are resolved against the directory of the
+// existing config or the working directory.
+func (p *Plugin) writeAnsibleConfig() (string, error) {
+	var err error
+
+	cfg := &ansible.Config{}
+	dir := "."
+
+	if base := baseAnsibleConfig(); base != "" && p.Settings.AnsibleConfigMerge {
+		log.Debug().Str("path", base).Msg("merge ansible config")
+
+		cfg, err = ansible.ReadConfig(base)
+		if err != nil {
+			return "", fmt.Errorf("failed to read ansible config: %w", err)
+		}
+
+		dir = filepath.Dir(base)
+	}
+
+	for _, section := range sortedKeys(p.Settings.AnsibleConfig) {
+		options := p.Settings.AnsibleConfig[section]
+
+		for _, key := range sortedKeys(options) {
+			cfg.Set(section, key, configValue(options[key]))
+		}
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	cfg.ResolvePaths(dir)
+
+	f, err := os.CreateTemp("", "ansible-*.cfg")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(cfg.String()); err != nil {
+		os.Remove(f.Name())
+
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// baseAnsibleConfig returns the path of the ansible.cfg that would be used by ansible
+// from the environment or the working directory.
+func baseAnsibleConfig() string {
+	if path := os.Getenv("ANSIBLE_CONFIG"); path != "" {
+		return path
+	}
+
+	if _, err := os.Stat(ansibleConfigFile); err == nil {
+		return ansibleConfigFile
+	}
+
+	return ""
+}
+
+func configValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, configValue(item))
+		}
+
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
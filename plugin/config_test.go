@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAnsibleConfig(t *testing.T) {
+	options := map[string]map[string]any{
+		"defaults": {
+			"forks":             20,
+			"host_key_checking": false,
+			"callbacks_enabled": []any{"timer", "profile_tasks"},
+		},
+		"ssh_connection": {
+			"pipelining": true,
+		},
+	}
+
+	tests := []struct {
+		name  string
+		merge bool
+		want  string
+	}{
+		{
+			name:  "merge with existing config",
+			merge: true,
+			want: "[defaults]\nforks = 20\nroles_path = <dir>/roles\ncallbacks_enabled = timer,profile_tasks\n" +
+				"host_key_checking = false\n\n[ssh_connection]\npipelining = true\n",
+		},
+		{
+			name: "without merge",
+			want: "[defaults]\ncallbacks_enabled = timer,profile_tasks\nforks = 20\n" +
+				"host_key_checking = false\n\n[ssh_connection]\npipelining = true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			base := filepath.Join(dir, "ansible.cfg")
+			require.NoError(t, os.WriteFile(base, []byte("[defaults]\nforks = 5\nroles_path = roles\n"), 0o600))
+			t.Setenv("ANSIBLE_CONFIG", base)
+
+			p := &Plugin{Settings: &Settings{AnsibleConfig: options, AnsibleConfigMerge: tt.merge}}
+
+			path, err := p.writeAnsibleConfig()
+			require.NoError(t, err)
+
+			defer os.Remove(path)
+
+			assert.Equal(t, filepath.Clean(os.TempDir()), filepath.Dir(path))
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, strings.ReplaceAll(tt.want, "<dir>", dir), string(got))
+		})
+	}
+}
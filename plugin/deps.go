@@ -0,0 +1,188 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
+)
+
+// virtualenv returns the commands to set up the isolated python environment, if any. A requested
+// ansible-core version that differs from the installed one is installed into its own environment.
+func (p *Plugin) virtualenv() ([]*plugin_exec.Cmd, *CacheEntry, error) {
+	if p.Settings.Offline {
+		p.Settings.Python.WheelDir = p.Settings.PythonWheelhouse
+	}
+
+	if p.Settings.installAnsible {
+		return p.ansibleVirtualenv()
+	}
+
+	if !p.Settings.PythonVirtualenv {
+		return nil, nil, nil
+	}
+
+	var err error
+
+	p.Settings.Python.Virtualenv, err = os.MkdirTemp("", "venv")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmds := []*plugin_exec.Cmd{p.Settings.Python.VirtualenvCreate()}
+
+	// With access to the system site packages, the bundled ansible is run by the virtual environment
+	// interpreter. Otherwise, ansible must be provided by the python requirements.
+	switch {
+	case p.Settings.Python.SystemSitePackages:
+		p.Settings.Ansible.Python = p.Settings.Python.Interpreter()
+	case p.Settings.AnsibleVersion != "":
+		p.Settings.Ansible.BinDir = p.Settings.Python.BinDir()
+		cmds = append(cmds, p.Settings.Python.PipInstallPackage(ansibleCorePackage(p.Settings.AnsibleVersion)))
+	default:
+		p.Settings.Ansible.BinDir = p.Settings.Python.BinDir()
+	}
+
+	return cmds, nil, nil
+}
+
+// ansibleVirtualenv returns the commands to install the requested ansible-core version into an
+// isolated environment. The environment is kept in the cache dir if configured, python requirements
+// are installed into the same environment.
+func (p *Plugin) ansibleVirtualenv() ([]*plugin_exec.Cmd, *CacheEntry, error) {
+	var (
+		entry *CacheEntry
+		err   error
+	)
+
+	if p.Settings.CacheDir != "" {
+		extra := []string{
+			p.Settings.AnsibleVersion, runtime.GOARCH, os.Getenv("PYTHON_VERSION"),
+			strconv.FormatBool(p.Settings.Python.SystemSitePackages),
+		}
+
+		entry, err = NewCacheEntry(
+			p.Settings.CacheDir, "ansible", extra, p.Settings.Python.Requirements, p.Settings.Python.Constraints,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read ansible cache: %w", err)
+		}
+
+		p.Settings.Python.Virtualenv = entry.Path
+	} else {
+		p.Settings.Python.Virtualenv, err = os.MkdirTemp("", "venv")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	p.Settings.Ansible.BinDir = p.Settings.Python.BinDir()
+
+	if entry != nil && entry.Hit {
+		log.Info().Str("path", entry.Path).Msg("restore ansible-core environment from cache")
+
+		return nil, entry, nil
+	}
+
+	cmds := []*plugin_exec.Cmd{
+		p.Settings.Python.VirtualenvCreate(),
+		p.Settings.Python.PipInstallPackage(ansibleCorePackage(p.Settings.AnsibleVersion)),
+	}
+
+	return cmds, entry, nil
+}
+
+func (p *Plugin) pipInstall() ([]*plugin_exec.Cmd, *CacheEntry, error) {
+	cmds := make([]*plugin_exec.Cmd, 0)
+
+	if p.Settings.Python.Requirements == "" {
+		return cmds, nil, nil
+	}
+
+	if p.Settings.Offline {
+		p.Settings.Python.WheelDir = p.Settings.PythonWheelhouse
+
+		return append(cmds, p.Settings.Python.PipInstall()), nil, nil
+	}
+
+	if p.Settings.CacheDir == "" {
+		return append(cmds, p.Settings.Python.PipInstall()), nil, nil
+	}
+
+	// Wheels are bound to the interpreter and platform they were built for.
+	extra := []string{runtime.GOARCH, os.Getenv("PYTHON_VERSION")}
+
+	entry, err := NewCacheEntry(
+		p.Settings.CacheDir, "pip", extra, p.Settings.Python.Requirements, p.Settings.Python.Constraints,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read python cache: %w", err)
+	}
+
+	p.Settings.Python.WheelDir = entry.Path
+
+	if entry.Hit {
+		log.Info().Str("path", entry.Path).Msg("restore python packages from cache")
+	} else {
+		cmds = append(cmds, p.Settings.Python.PipWheel())
+	}
+
+	return append(cmds, p.Settings.Python.PipInstall()), entry, nil
+}
+
+func (p *Plugin) galaxyInstall() ([]*plugin_exec.Cmd, *CacheEntry, error) {
+	files := p.Settings.Ansible.GalaxyRequirementFiles()
+
+	// Exporting the galaxy roles path overrides the roles path of the ansible config.
+	p.Settings.Ansible.ConfigRolesPath = p.configRolesPath()
+
+	if p.Settings.CacheDir == "" || len(files) == 0 {
+		cmds, err := p.Settings.Ansible.GalaxyInstall()
+
+		return cmds, nil, err
+	}
+
+	entry, err := NewCacheEntry(p.Settings.CacheDir, "galaxy", nil, files...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read galaxy cache: %w", err)
+	}
+
+	p.Settings.Ansible.GalaxyRolesPath = filepath.Join(entry.Path, "roles")
+	p.Settings.Ansible.GalaxyCollectionsPath = filepath.Join(entry.Path, "collections")
+
+	if entry.Hit {
+		log.Info().Str("path", entry.Path).Msg("restore galaxy roles and collections from cache")
+
+		return nil, entry, nil
+	}
+
+	cmds, err := p.Settings.Ansible.GalaxyInstall()
+
+	return cmds, entry, err
+}
+
+func (p *Plugin) logGalaxyInstalled() error {
+	pkgs, err := p.Settings.Ansible.GalaxyInstalled()
+	if err != nil {
+		return err
+	}
+
+	for _, pkg := range pkgs {
+		version := pkg.Version
+		if version == "" {
+			version = "unknown"
+		}
+
+		log.Info().Str("name", pkg.Name).Str("version", version).Str("path", pkg.Path).Msgf("installed galaxy %s", pkg.Kind)
+	}
+
+	return nil
+}
+
+func ansibleCorePackage(version string) string {
+	return fmt.Sprintf("ansible-core==%s", version)
+}
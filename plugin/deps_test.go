@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualenv(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   *Settings
+		wantArgs   [][]string
+		wantPython bool
+		wantBinDir bool
+	}{
+		{
+			name:     "without virtualenv",
+			settings: &Settings{},
+			wantArgs: [][]string{},
+		},
+		{
+			name: "with system site packages",
+			settings: func() *Settings {
+				s := &Settings{PythonVirtualenv: true}
+				s.Python.SystemSitePackages = true
+
+				return s
+			}(),
+			wantArgs:   [][]string{{"/usr/local/bin/python3", "-m", "venv", "--system-site-packages", "<venv>"}},
+			wantPython: true,
+		},
+		{
+			name:       "without system site packages",
+			settings:   &Settings{PythonVirtualenv: true},
+			wantArgs:   [][]string{{"/usr/local/bin/python3", "-m", "venv", "<venv>"}},
+			wantBinDir: true,
+		},
+		{
+			name:     "with ansible version",
+			settings: &Settings{AnsibleVersion: "0.0.0-test", installAnsible: true},
+			wantArgs: [][]string{
+				{"/usr/local/bin/python3", "-m", "venv", "<venv>"},
+				{"<venv>/bin/pip", "install", "--upgrade", "ansible-core==0.0.0-test"},
+			},
+			wantBinDir: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: tt.settings}
+
+			cmds, entry, err := p.virtualenv()
+			require.NoError(t, err)
+			assert.Nil(t, entry)
+
+			got := make([][]string, 0)
+
+			for _, cmd := range cmds {
+				args := make([]string, 0)
+
+				for _, arg := range cmd.Args {
+					if venv := p.Settings.Python.Virtualenv; venv != "" {
+						arg = strings.Replace(arg, venv, "<venv>", 1)
+					}
+
+					args = append(args, arg)
+				}
+
+				got = append(got, args)
+			}
+
+			if p.Settings.Python.Virtualenv != "" {
+				assert.NoError(t, os.RemoveAll(p.Settings.Python.Virtualenv))
+			}
+
+			assert.Equal(t, tt.wantArgs, got)
+			assert.Equal(t, tt.wantPython, p.Settings.Ansible.Python != "")
+			assert.Equal(t, tt.wantBinDir, p.Settings.Ansible.BinDir != "")
+		})
+	}
+}
+
+func TestAnsibleVirtualenvCache(t *testing.T) {
+	p := &Plugin{Settings: &Settings{
+		AnsibleVersion: "0.0.0-test",
+		CacheDir:       t.TempDir(),
+		installAnsible: true,
+	}}
+
+	cmds, entry, err := p.virtualenv()
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.False(t, entry.Hit)
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, filepath.Join(entry.Path, "bin"), p.Settings.Ansible.BinDir)
+
+	require.NoError(t, entry.Save())
+
+	p.Settings.Ansible.BinDir = ""
+
+	cmds, entry, err = p.virtualenv()
+	require.NoError(t, err)
+	assert.True(t, entry.Hit)
+	assert.Empty(t, cmds)
+	assert.Equal(t, filepath.Join(entry.Path, "bin"), p.Settings.Ansible.BinDir)
+}
+
+func TestPipInstallCache(t *testing.T) {
+	dir := t.TempDir()
+	req := filepath.Join(dir, "requirements.txt")
+	constraints := filepath.Join(dir, "constraints.txt")
+
+	require.NoError(t, os.WriteFile(req, []byte("requests\n"), 0o600))
+	require.NoError(t, os.WriteFile(constraints, []byte("requests==2.31.0\n"), 0o600))
+
+	p := &Plugin{Settings: &Settings{CacheDir: filepath.Join(dir, "cache")}}
+	p.Settings.Python.Requirements = req
+	p.Settings.Python.Constraints = constraints
+
+	_, entry, err := p.pipInstall()
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	require.NoError(t, entry.Save())
+
+	_, cached, err := p.pipInstall()
+	require.NoError(t, err)
+	assert.True(t, cached.Hit)
+
+	require.NoError(t, os.WriteFile(constraints, []byte("requests==2.32.3\n"), 0o600))
+
+	cmds, changed, err := p.pipInstall()
+	require.NoError(t, err)
+	assert.False(t, changed.Hit)
+	assert.NotEqual(t, entry.Path, changed.Path)
+	assert.Len(t, cmds, 2)
+}
+
+func TestGalaxyInstallCache(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "ansible.cfg")
+	req := filepath.Join(dir, "requirements.yml")
+
+	require.NoError(t, os.WriteFile(cfg, []byte("[defaults]\nroles_path = roles\n"), 0o600))
+	require.NoError(t, os.WriteFile(req, []byte("roles:\n  - name: geerlingguy.nginx\n"), 0o600))
+	t.Setenv("ANSIBLE_CONFIG", cfg)
+	t.Setenv("ANSIBLE_ROLES_PATH", "")
+
+	p := &Plugin{Settings: &Settings{CacheDir: filepath.Join(dir, "cache")}}
+	p.Settings.Ansible.GalaxyRequirements = req
+
+	_, entry, err := p.galaxyInstall()
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+
+	assert.Equal(t, filepath.Join(entry.Path, "roles"), p.Settings.Ansible.GalaxyRolesPath)
+	assert.Contains(t, p.Settings.Ansible.Environ(),
+		"ANSIBLE_ROLES_PATH="+filepath.Join(entry.Path, "roles")+":"+filepath.Join(dir, "roles"))
+}
+
+func TestValidateGalaxyPathWithCacheDir(t *testing.T) {
+	req := filepath.Join(t.TempDir(), "requirements.yml")
+	require.NoError(t, os.WriteFile(req, []byte("roles:\n  - name: geerlingguy.nginx\n"), 0o600))
+
+	p := &Plugin{Settings: &Settings{CacheDir: t.TempDir()}}
+	p.Settings.Ansible.Inventories = []string{"localhost,"}
+	p.Settings.Ansible.Playbooks = []string{req}
+	p.Settings.Ansible.GalaxyRequirements = req
+	p.Settings.Ansible.GalaxyRolesPath = "/build/roles"
+
+	assert.ErrorIs(t, p.Validate(), ErrGalaxyPathWithCacheDir)
+}
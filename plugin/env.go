@@ -0,0 +1,120 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v3"
+)
+
+// essentialEnv is inherited from the step environment even if an allowlist is configured,
+// as ansible and its connection plugins do not work without it.
+//
+//nolint:gochecknoglobals
+var essentialEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_*", "TERM", "TMPDIR", "TZ", "SSH_AUTH_SOCK"}
+
+// secretEnv returns the environment variables of the flags bound to settings tagged as
+// secret, which are either passed to ansible as temporary files or only used by the plugin.
+// They are never inherited by the ansible processes.
+//
+//nolint:gochecknoglobals
+var secretEnv = sync.OnceValue(func() []string {
+	settings := &Settings{}
+	fields := reflect.ValueOf(settings).Elem()
+	secrets := make([]any, 0)
+
+	for i := range fields.NumField() {
+		if fields.Type().Field(i).Tag.Get("secret") == "true" {
+			secrets = append(secrets, fields.Field(i).Addr().Interface())
+		}
+	}
+
+	env := make([]string, 0)
+
+	for _, flag := range Flags(settings, "") {
+		f, ok := flag.(interface{ GetEnvVars() []string })
+		if ok && slices.Contains(secrets, flagDestination(flag)) {
+			env = append(env, f.GetEnvVars()...)
+		}
+	}
+
+	return env
+})
+
+// flagDestination returns the pointer to the setting a flag is bound to.
+func flagDestination(flag cli.Flag) any {
+	switch f := flag.(type) {
+	case *cli.StringFlag:
+		return f.Destination
+	case *cli.GenericFlag:
+		if v, ok := f.Value.(interface{ destination() any }); ok {
+			return v.destination()
+		}
+	}
+
+	return nil
+}
+
+// environ returns the environment of the ansible processes. Variables set by the plugin
+// take precedence over the user-defined ones, which take precedence over the inherited ones.
+func (p *Plugin) environ() []string {
+	env := p.inheritedEnv(os.Environ())
+
+	if p.Settings.ForceColor {
+		env = append(env, "ANSIBLE_FORCE_COLOR=1")
+	}
+
+	for _, key := range sortedKeys(p.Settings.AnsibleEnv) {
+		name := strings.ToUpper(key)
+		if !strings.HasPrefix(name, "ANSIBLE_") {
+			name = fmt.Sprintf("ANSIBLE_%s", name)
+		}
+
+		env = append(env, fmt.Sprintf("%s=%s", name, configValue(p.Settings.AnsibleEnv[key])))
+	}
+
+	for _, key := range sortedKeys(p.Settings.Env) {
+		env = append(env, fmt.Sprintf("%s=%s", key, p.Settings.Env[key]))
+	}
+
+	env = append(env, p.Settings.Python.Environ()...)
+
+	return append(env, p.Settings.Ansible.Environ()...)
+}
+
+// inheritedEnv filters the given environment by the configured allow and deny lists.
+func (p *Plugin) inheritedEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+
+	for _, item := range environ {
+		name, _, _ := strings.Cut(item, "=")
+
+		if matchEnv(name, secretEnv()) || matchEnv(name, p.Settings.EnvDeny) {
+			continue
+		}
+
+		if len(p.Settings.EnvAllow) > 0 && !matchEnv(name, p.Settings.EnvAllow) && !matchEnv(name, essentialEnv) {
+			continue
+		}
+
+		env = append(env, item)
+	}
+
+	return env
+}
+
+// matchEnv reports whether the variable name matches any of the given glob patterns.
+func matchEnv(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,115 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrEnvironmentNotFound = errors.New("no environment matches")
+
+// Environment is a set of setting overrides applied if the branch or tag of the
+// pipeline matches one of the patterns.
+type Environment struct {
+	Name        string         `yaml:"name"`
+	Branches    stringList     `yaml:"branch"`
+	Tags        stringList     `yaml:"tag"`
+	Inventories stringList     `yaml:"inventory"`
+	Limit       string         `yaml:"limit"`
+	ExtraVars   map[string]any `yaml:"extra_vars"`
+	VaultID     string         `yaml:"vault_id"`
+	Check       *bool          `yaml:"check"`
+}
+
+// stringList is a list of strings that can also be given as single string.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = stringList{value.Value}
+
+		return nil
+	}
+
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+
+	*l = list
+
+	return nil
+}
+
+// matches reports whether the environment applies to the given branch or tag. Tag
+// patterns are only matched for tags, branch patterns only for branches.
+func (e *Environment) matches(branch, tag string) bool {
+	patterns, ref := e.Branches, branch
+	if tag != "" {
+		patterns, ref = e.Tags, tag
+	}
+
+	if ref == "" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, ref); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveEnvironment applies the overrides of the first environment matching the
+// branch or tag of the pipeline.
+func (p *Plugin) resolveEnvironment() error {
+	meta := p.Settings.metadata
+
+	for _, env := range p.Settings.Environments {
+		if !env.matches(meta.Branch, meta.Tag) {
+			continue
+		}
+
+		log.Info().Str("environment", env.Name).Str("branch", meta.Branch).Str("tag", meta.Tag).
+			Msg("resolved environment")
+
+		p.Settings.environment = env.Name
+		p.applyEnvironment(env)
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: branch %q, tag %q", ErrEnvironmentNotFound, meta.Branch, meta.Tag)
+}
+
+func (p *Plugin) applyEnvironment(env *Environment) {
+	if len(env.Inventories) > 0 {
+		p.Settings.Ansible.Inventories = env.Inventories
+	}
+
+	if env.Limit != "" {
+		p.Settings.Ansible.Limit = env.Limit
+	}
+
+	if env.VaultID != "" {
+		p.Settings.Ansible.VaultID = env.VaultID
+	}
+
+	if env.Check != nil {
+		p.Settings.Ansible.Check = *env.Check
+	}
+
+	if len(env.ExtraVars) > 0 {
+		if p.Settings.ExtraVars == nil {
+			p.Settings.ExtraVars = make(map[string]any)
+		}
+
+		maps.Copy(p.Settings.ExtraVars, env.ExtraVars)
+	}
+}
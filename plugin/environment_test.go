@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+const environmentsSetting = `[
+  {"name": "staging", "branch": "main", "inventory": "inventories/staging.yml", "extra_vars": {"replicas": 1}},
+  {"name": "review", "branch": ["feature/*", "fix/*"], "limit": "review", "check": true},
+  {"name": "production", "tag": "v*", "inventory": ["inventories/prod-eu.yml", "inventories/prod-us.yml"],
+   "vault_id": "prod@vault.txt"}
+]`
+
+func TestResolveEnvironment(t *testing.T) {
+	tests := []struct {
+		name      string
+		meta      *metadata
+		want      string
+		wantInv   []string
+		wantLimit string
+		wantVault string
+		wantCheck bool
+		wantVars  map[string]any
+		wantErr   error
+	}{
+		{
+			name:     "branch",
+			meta:     &metadata{Branch: "main"},
+			want:     "staging",
+			wantInv:  []string{"inventories/staging.yml"},
+			wantVars: map[string]any{"replicas": 1, "app": "web"},
+		},
+		{
+			name:      "branch pattern",
+			meta:      &metadata{Branch: "fix/login"},
+			want:      "review",
+			wantInv:   []string{"inventories/default.yml"},
+			wantLimit: "review",
+			wantCheck: true,
+			wantVars:  map[string]any{"app": "web"},
+		},
+		{
+			name:      "tag",
+			meta:      &metadata{Branch: "main", Tag: "v1.2.0"},
+			want:      "production",
+			wantInv:   []string{"inventories/prod-eu.yml", "inventories/prod-us.yml"},
+			wantVault: "prod@vault.txt",
+			wantVars:  map[string]any{"app": "web"},
+		},
+		{
+			name:    "no match",
+			meta:    &metadata{Branch: "develop"},
+			wantErr: ErrEnvironmentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &Settings{
+				Ansible:   ansible.Ansible{Inventories: []string{"inventories/default.yml"}},
+				ExtraVars: map[string]any{"app": "web"},
+				metadata:  tt.meta,
+			}
+			require.NoError(t, newYAMLValue(&settings.Environments).Set(environmentsSetting))
+
+			p := &Plugin{Settings: settings}
+
+			err := p.resolveEnvironment()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, settings.environment)
+			assert.Equal(t, tt.wantInv, settings.Ansible.Inventories)
+			assert.Equal(t, tt.wantLimit, settings.Ansible.Limit)
+			assert.Equal(t, tt.wantVault, settings.Ansible.VaultID)
+			assert.Equal(t, tt.wantCheck, settings.Ansible.Check)
+			assert.Equal(t, tt.wantVars, settings.ExtraVars)
+		})
+	}
+}
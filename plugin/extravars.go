@@ -0,0 +1,54 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	plugin_file "github.com/thegeeklab/wp-plugin-go/v6/file"
+)
+
+// validateExtraVars ensures that files referenced by `@file` extra vars exist.
+func (p *Plugin) validateExtraVars() error {
+	for _, v := range p.Settings.Ansible.ExtraVars {
+		file, ok := strings.CutPrefix(v, "@")
+		if !ok {
+			continue
+		}
+
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("%w: %w", ErrExtraVarsInvalid, err)
+		}
+	}
+
+	return nil
+}
+
+// addCIVars adds the build metadata as dict under the configured namespace to the
+// structured extra vars. User-defined extra vars with the same name take precedence.
+func (p *Plugin) addCIVars() {
+	if p.Settings.ExtraVars == nil {
+		p.Settings.ExtraVars = make(map[string]any)
+	}
+
+	if _, ok := p.Settings.ExtraVars[p.Settings.CIVarsNamespace]; ok {
+		log.Warn().Str("namespace", p.Settings.CIVarsNamespace).Msg("ci vars overridden by extra vars")
+
+		return
+	}
+
+	p.Settings.ExtraVars[p.Settings.CIVarsNamespace] = p.Settings.metadata.vars()
+}
+
+// writeExtraVars serializes the structured extra vars to a temporary JSON file
+// and returns its path.
+func (p *Plugin) writeExtraVars() (string, error) {
+	content, err := json.Marshal(p.Settings.ExtraVars)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrExtraVarsInvalid, err)
+	}
+
+	return plugin_file.WriteTmpFile("extraVars", string(content))
+}
@@ -0,0 +1,110 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestValidateExtraVars(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "vars.yml")
+	require.NoError(t, os.WriteFile(file, []byte("var1: value1\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		vars    []string
+		wantErr error
+	}{
+		{name: "existing file", vars: []string{"var1=value1", "@" + file}},
+		{name: "missing file", vars: []string{"@" + file + ".missing"}, wantErr: ErrExtraVarsInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{Ansible: ansible.Ansible{ExtraVars: tt.vars}}}
+
+			err := p.validateExtraVars()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWriteExtraVars(t *testing.T) {
+	data := map[string]any{"hosts": []any{"web1", "web2"}, "replicas": 3}
+	p := &Plugin{Settings: &Settings{ExtraVars: data}}
+
+	path, err := p.writeExtraVars()
+	require.NoError(t, err)
+
+	defer os.Remove(path)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	got := make(map[string]any)
+	require.NoError(t, json.Unmarshal(content, &got))
+	assert.Equal(t, map[string]any{"hosts": []any{"web1", "web2"}, "replicas": float64(3)}, got)
+}
+
+func TestAddCIVars(t *testing.T) {
+	t.Setenv("CI_COMMIT_SHA", "a1b2c3")
+	t.Setenv("CI_COMMIT_BRANCH", "main")
+	t.Setenv("CI_COMMIT_TAG", "")
+	t.Setenv("CI_PIPELINE_NUMBER", "42")
+	t.Setenv("CI_PIPELINE_URL", "https://ci.example.com/repos/1/pipeline/42")
+	t.Setenv("CI_COMMIT_AUTHOR", "octocat")
+
+	want := map[string]any{
+		"commit_sha":   "a1b2c3",
+		"branch":       "main",
+		"tag":          "",
+		"build_number": "42",
+		"pipeline_url": "https://ci.example.com/repos/1/pipeline/42",
+		"author":       "octocat",
+	}
+
+	tests := []struct {
+		name      string
+		extraVars map[string]any
+		want      map[string]any
+	}{
+		{
+			name: "without extra vars",
+			want: map[string]any{"ci": want},
+		},
+		{
+			name:      "with extra vars",
+			extraVars: map[string]any{"replicas": 3},
+			want:      map[string]any{"ci": want, "replicas": 3},
+		},
+		{
+			name:      "namespace overridden by extra vars",
+			extraVars: map[string]any{"ci": "custom"},
+			want:      map[string]any{"ci": "custom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{
+				ExtraVars:       tt.extraVars,
+				CIVarsNamespace: "ci",
+				metadata:        newMetadata(),
+			}}
+
+			p.addCIVars()
+			assert.Equal(t, tt.want, p.Settings.ExtraVars)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/graph"
+)
+
+const (
+	GraphFormatJSON = "json"
+	GraphFormatDOT  = "dot"
+)
+
+var (
+	ErrReferenceNotFound = errors.New("referenced files or roles not found")
+	ErrGraphFormat       = errors.New("unsupported dependency graph format")
+)
+
+// analyzePlaybooks builds the dependency graph of the playbooks to export it, validate
+// the references and select the playbooks affected by changes.
+func (p *Plugin) analyzePlaybooks() error {
+	g, err := p.buildGraph()
+	if err != nil {
+		return err
+	}
+
+	if p.Settings.DependencyGraph != "" {
+		if err := p.writeGraph(g); err != nil {
+			return err
+		}
+	}
+
+	if p.Settings.ValidateReferences {
+		if err := validateReferences(g); err != nil {
+			return err
+		}
+	}
+
+	if p.Settings.ChangedOnly {
+		return p.selectChanged(g)
+	}
+
+	return nil
+}
+
+// buildGraph creates the dependency graph of the playbooks.
+func (p *Plugin) buildGraph() (*graph.Graph, error) {
+	opts := graph.Options{
+		RolesPath:   p.rolesPath(),
+		Inventories: p.Settings.Ansible.Inventories,
+		Global: []string{
+			baseAnsibleConfig(),
+			p.Settings.Ansible.GalaxyRequirements,
+			p.Settings.Ansible.GalaxyCollectionRequirements,
+			p.Settings.Python.Requirements,
+			p.Settings.Python.Constraints,
+		},
+	}
+
+	for _, file := range p.Settings.Ansible.GalaxyRequirementFiles() {
+		req, err := ansible.ReadGalaxyRequirements(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, role := range req.Roles {
+			opts.ExternalRoles = append(opts.ExternalRoles, role.RoleName())
+		}
+	}
+
+	return graph.Build(p.Settings.Ansible.Playbooks, opts)
+}
+
+// rolesPath returns the configured role directories from the plugin settings, the
+// environment and the ansible config.
+func (p *Plugin) rolesPath() []string {
+	return append(filepath.SplitList(p.Settings.Ansible.GalaxyRolesPath), p.configRolesPath()...)
+}
+
+// configRolesPath returns the role directories from the environment and the ansible
+// config.
+func (p *Plugin) configRolesPath() []string {
+	paths := filepath.SplitList(os.Getenv("ANSIBLE_ROLES_PATH"))
+
+	if value, ok := p.Settings.AnsibleConfig["defaults"]["roles_path"]; ok {
+		paths = append(paths, filepath.SplitList(configValue(value))...)
+	}
+
+	if base := baseAnsibleConfig(); base != "" {
+		if cfg, err := ansible.ReadConfig(base); err == nil {
+			value, _ := cfg.Get("defaults", "roles_path")
+
+			for _, path := range filepath.SplitList(value) {
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(filepath.Dir(base), path)
+				}
+
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return paths
+}
+
+// writeGraph writes the dependency graph to the configured file.
+func (p *Plugin) writeGraph(g *graph.Graph) error {
+	var (
+		content []byte
+		err     error
+	)
+
+	switch p.Settings.DependencyGraphFormat {
+	case GraphFormatJSON:
+		content, err = g.JSON()
+		if err != nil {
+			return err
+		}
+	case GraphFormatDOT:
+		content = []byte(g.DOT())
+	default:
+		return fmt.Errorf("%w: %s", ErrGraphFormat, p.Settings.DependencyGraphFormat)
+	}
+
+	log.Info().Str("path", p.Settings.DependencyGraph).Msg("write dependency graph")
+
+	return os.WriteFile(p.Settings.DependencyGraph, content, 0o644) //nolint:gosec,mnd
+}
+
+// validateReferences returns an error if any of the files or roles referenced by the
+// playbooks does not exist.
+func validateReferences(g *graph.Graph) error {
+	missing := g.Missing()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(missing))
+
+	for _, node := range missing {
+		log.Error().Str("kind", string(node.Kind)).Str("reference", node.ID).
+			Strs("referenced-by", g.Referrers(node.ID)).Msg("reference not found")
+
+		ids = append(ids, node.ID)
+	}
+
+	return fmt.Errorf("%w: %s", ErrReferenceNotFound, strings.Join(ids, ", "))
+}
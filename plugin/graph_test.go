@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestAnalyzePlaybooks(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	require.NoError(t, os.WriteFile("site.yml", []byte("- hosts: all\n  roles:\n    - nginx\n    - docker\n"), 0o600))
+	require.NoError(t, os.WriteFile("requirements.yml", []byte("roles:\n  - name: docker\n"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join("shared", "nginx"), 0o755))
+
+	tests := []struct {
+		name      string
+		settings  *Settings
+		wantGraph string
+		wantErr   error
+	}{
+		{
+			name:     "missing role",
+			settings: &Settings{ValidateReferences: true},
+			wantErr:  ErrReferenceNotFound,
+		},
+		{
+			name: "role from roles path",
+			settings: &Settings{
+				ValidateReferences: true,
+				AnsibleConfig:      map[string]map[string]any{"defaults": {"roles_path": "shared"}},
+			},
+		},
+		{
+			name: "dot graph",
+			settings: &Settings{
+				DependencyGraph:       "graph.dot",
+				DependencyGraphFormat: GraphFormatDOT,
+			},
+			wantGraph: "digraph dependencies {",
+		},
+		{
+			name: "json graph",
+			settings: &Settings{
+				DependencyGraph:       "graph.json",
+				DependencyGraphFormat: GraphFormatJSON,
+			},
+			wantGraph: `"playbooks": [`,
+		},
+		{
+			name: "invalid format",
+			settings: &Settings{
+				DependencyGraph:       "graph.svg",
+				DependencyGraphFormat: "svg",
+			},
+			wantErr: ErrGraphFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings.Ansible = ansible.Ansible{Playbooks: []string{"site.yml"}, GalaxyRequirements: "requirements.yml"}
+
+			p := &Plugin{Settings: tt.settings}
+
+			err := p.analyzePlaybooks()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.wantGraph != "" {
+				content, err := os.ReadFile(tt.settings.DependencyGraph)
+				require.NoError(t, err)
+				assert.Contains(t, string(content), tt.wantGraph)
+			}
+		})
+	}
+}
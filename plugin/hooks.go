@@ -0,0 +1,95 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
+)
+
+const (
+	shellBin = "/bin/sh"
+
+	runStatusSuccess = "success"
+	runStatusFailure = "failure"
+)
+
+var ErrHookFailed = errors.New("hook command failed")
+
+// runWithHooks runs the pre-commands and the playbooks, followed by the post-commands
+// matching the outcome of the run. The commands are skipped in pull request safe mode.
+func (p *Plugin) runWithHooks(run func() error) error {
+	if p.Settings.safeMode {
+		if len(p.Settings.PreCommands) > 0 || len(p.Settings.PostCommands) > 0 ||
+			len(p.Settings.PostCommandsOnSuccess) > 0 || len(p.Settings.PostCommandsOnFailure) > 0 {
+			log.Info().Msg("pull request safe mode enabled, skip pre and post commands")
+		}
+
+		return run()
+	}
+
+	start := time.Now()
+
+	err := p.runHooks(p.Settings.PreCommands, nil)
+	if err == nil {
+		err = run()
+	}
+
+	status := runStatusSuccess
+	post := p.Settings.PostCommandsOnSuccess
+
+	if err != nil {
+		status = runStatusFailure
+		post = p.Settings.PostCommandsOnFailure
+	}
+
+	env := []string{
+		fmt.Sprintf("ANSIBLE_RUN_STATUS=%s", status),
+		fmt.Sprintf("ANSIBLE_RUN_DURATION=%d", int(time.Since(start).Seconds())),
+	}
+
+	if err != nil {
+		env = append(env, fmt.Sprintf("ANSIBLE_RUN_ERROR=%s", err))
+	}
+
+	return errors.Join(err, p.runHooks(post, env), p.runHooks(p.Settings.PostCommands, env))
+}
+
+// runHooks runs the commands in a shell with the environment of the ansible commands.
+// The temporary private key and vault password files are exposed to the commands. All
+// commands are run even if one of them fails, the errors are joined.
+func (p *Plugin) runHooks(commands, env []string) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	env = append(p.environ(), env...)
+	env = append(env, fmt.Sprintf("ANSIBLE_RUN_CHECK=%s", strconv.FormatBool(p.Settings.Ansible.Check)))
+
+	if p.Settings.Ansible.PrivateKeyFile != "" {
+		env = append(env, fmt.Sprintf("ANSIBLE_PRIVATE_KEY_FILE=%s", p.Settings.Ansible.PrivateKeyFile))
+	}
+
+	if p.Settings.Ansible.VaultPasswordFile != "" {
+		env = append(env, fmt.Sprintf("ANSIBLE_VAULT_PASSWORD_FILE=%s", p.Settings.Ansible.VaultPasswordFile))
+	}
+
+	errs := make([]error, 0)
+
+	for _, command := range commands {
+		cmd := plugin_exec.Command(shellBin, "-c", command)
+		cmd.Env = env
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrHookFailed, command, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
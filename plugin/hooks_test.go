@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestRunWithHooks(t *testing.T) {
+	errRun := errors.New("exit status 2")
+
+	tests := []struct {
+		name     string
+		settings *Settings
+		runErr   error
+		want     string
+		wantRun  bool
+		wantErr  []error
+	}{
+		{
+			name: "success",
+			settings: &Settings{
+				PreCommands:           []string{`echo "pre $ANSIBLE_PRIVATE_KEY_FILE" >> "$LOG"`},
+				PostCommands:          []string{`echo "always $ANSIBLE_RUN_STATUS" >> "$LOG"`},
+				PostCommandsOnSuccess: []string{`echo "success $ANSIBLE_RUN_STATUS" >> "$LOG"`},
+				PostCommandsOnFailure: []string{`echo "failure" >> "$LOG"`},
+				Ansible:               ansible.Ansible{PrivateKeyFile: "/tmp/privateKey"},
+			},
+			want:    "pre /tmp/privateKey\nsuccess success\nalways success\n",
+			wantRun: true,
+		},
+		{
+			name: "check mode",
+			settings: &Settings{
+				PreCommands:  []string{`echo "pre $ANSIBLE_RUN_CHECK" >> "$LOG"`},
+				PostCommands: []string{`echo "always $ANSIBLE_RUN_CHECK" >> "$LOG"`},
+				Ansible:      ansible.Ansible{Check: true},
+			},
+			want:    "pre true\nalways true\n",
+			wantRun: true,
+		},
+		{
+			name: "safe mode",
+			settings: &Settings{
+				PreCommands:  []string{"exit 1"},
+				PostCommands: []string{"exit 1"},
+				Ansible:      ansible.Ansible{Check: true},
+				safeMode:     true,
+			},
+			wantRun: true,
+		},
+		{
+			name: "run failure",
+			settings: &Settings{
+				PostCommands:          []string{`echo "always $ANSIBLE_RUN_STATUS" >> "$LOG"`},
+				PostCommandsOnSuccess: []string{`echo "success" >> "$LOG"`},
+				PostCommandsOnFailure: []string{`echo "failure $ANSIBLE_RUN_ERROR" >> "$LOG"`},
+			},
+			runErr:  errRun,
+			want:    "failure exit status 2\nalways failure\n",
+			wantRun: true,
+			wantErr: []error{errRun},
+		},
+		{
+			name: "pre command failure",
+			settings: &Settings{
+				PreCommands:           []string{"exit 1"},
+				PostCommandsOnFailure: []string{`echo "failure" >> "$LOG"`},
+			},
+			want:    "failure\n",
+			wantErr: []error{ErrHookFailed},
+		},
+		{
+			name: "post command failure",
+			settings: &Settings{
+				PostCommands: []string{"exit 1"},
+			},
+			runErr:  errRun,
+			wantRun: true,
+			wantErr: []error{errRun, ErrHookFailed},
+		},
+		{
+			name: "outcome command failure",
+			settings: &Settings{
+				PostCommands:          []string{`echo "always $ANSIBLE_RUN_STATUS" >> "$LOG"`},
+				PostCommandsOnFailure: []string{"exit 1", `echo "failure" >> "$LOG"`},
+			},
+			runErr:  errRun,
+			want:    "failure\nalways failure\n",
+			wantRun: true,
+			wantErr: []error{errRun, ErrHookFailed},
+		},
+		{
+			name: "pre command failure continues",
+			settings: &Settings{
+				PreCommands:  []string{"exit 1", `echo "pre" >> "$LOG"`},
+				PostCommands: []string{`echo "always $ANSIBLE_RUN_STATUS" >> "$LOG"`},
+			},
+			want:    "pre\nalways failure\n",
+			wantErr: []error{ErrHookFailed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := filepath.Join(t.TempDir(), "hooks.log")
+			t.Setenv("LOG", log)
+
+			p := &Plugin{Settings: tt.settings}
+			ran := false
+
+			err := p.runWithHooks(func() error {
+				ran = true
+
+				return tt.runErr
+			})
+
+			assert.Equal(t, tt.wantRun, ran)
+
+			for _, wantErr := range tt.wantErr {
+				assert.ErrorIs(t, err, wantErr)
+			}
+
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+			}
+
+			if tt.want != "" {
+				content, err := os.ReadFile(log)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, string(content))
+			}
+		})
+	}
+}
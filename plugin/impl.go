@@ -2,13 +2,29 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
 	plugin_file "github.com/thegeeklab/wp-plugin-go/v6/file"
 )
 
+var (
+	ErrPythonWheelhouseRequired = errors.New("python wheelhouse is required in offline mode")
+	ErrGalaxyVendorPathRequired = errors.New("galaxy vendor path is required in offline mode")
+	ErrNotADirectory            = errors.New("not a directory")
+	ErrInventoryRequired        = errors.New("inventory is required")
+	ErrGalaxyPathWithCacheDir   = errors.New("galaxy roles and collections paths can not be used with a cache dir")
+
+	ErrPythonRequirementsRequired = errors.New(
+		"python requirements providing ansible are required for a virtualenv without system site packages",
+	)
+)
+
 func (p *Plugin) run(_ context.Context) error {
 	if err := p.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
@@ -23,20 +39,118 @@ func (p *Plugin) run(_ context.Context) error {
 
 // Validate handles the settings validation of the plugin.
 func (p *Plugin) Validate() error {
+	p.Settings.metadata = newMetadata()
+
+	if len(p.Settings.Environments) > 0 {
+		if err := p.resolveEnvironment(); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Settings.Ansible.Inventories) == 0 {
+		return ErrInventoryRequired
+	}
+
+	if p.Settings.Templates {
+		if err := p.renderSettings(); err != nil {
+			return err
+		}
+	}
+
+	p.validatePullRequest()
+
 	if err := p.Settings.Ansible.GetPlaybooks(); err != nil {
 		return err
 	}
 
+	if p.Settings.ChangedOnly || p.Settings.ValidateReferences || p.Settings.DependencyGraph != "" {
+		if err := p.analyzePlaybooks(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.validateNotifications(); err != nil {
+		return err
+	}
+
+	if err := p.validateMetrics(); err != nil {
+		return err
+	}
+
+	if p.Settings.LockBackend != "" {
+		if err := p.validateLock(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.validateExtraVars(); err != nil {
+		return err
+	}
+
+	if p.Settings.CIVars {
+		p.addCIVars()
+	}
+
+	if p.Settings.PythonVirtualenv && !p.Settings.Python.SystemSitePackages &&
+		p.Settings.Python.Requirements == "" && p.Settings.AnsibleVersion == "" {
+		return ErrPythonRequirementsRequired
+	}
+
+	if p.Settings.Offline {
+		if err := p.validateOffline(); err != nil {
+			return err
+		}
+	}
+
+	if p.Settings.CacheDir != "" && len(p.Settings.Ansible.GalaxyRequirementFiles()) > 0 &&
+		(p.Settings.Ansible.GalaxyRolesPath != "" || p.Settings.Ansible.GalaxyCollectionsPath != "") {
+		return ErrGalaxyPathWithCacheDir
+	}
+
+	return p.resolveAnsibleInstall()
+}
+
+func (p *Plugin) validateOffline() error {
+	p.Settings.Ansible.GalaxyOffline = true
+
+	if p.Settings.Python.Requirements != "" || p.Settings.AnsibleVersion != "" {
+		if p.Settings.PythonWheelhouse == "" {
+			return ErrPythonWheelhouseRequired
+		}
+
+		if err := requireDir(p.Settings.PythonWheelhouse); err != nil {
+			return fmt.Errorf("invalid python wheelhouse: %w", err)
+		}
+	}
+
+	if len(p.Settings.Ansible.GalaxyRequirementFiles()) > 0 {
+		if p.Settings.Ansible.GalaxyVendorPath == "" {
+			return ErrGalaxyVendorPathRequired
+		}
+
+		if err := requireDir(p.Settings.Ansible.GalaxyVendorPath); err != nil {
+			return fmt.Errorf("invalid galaxy vendor path: %w", err)
+		}
+	}
+
 	return nil
 }
 
 // Execute provides the implementation of the plugin.
-func (p *Plugin) Execute() error {
-	var err error
+func (p *Plugin) Execute() (err error) {
+	if p.Settings.skipRun {
+		log.Info().Msg("no playbooks affected by changes, skip run")
 
-	batchCmd := make([]*plugin_exec.Cmd, 0)
+		return nil
+	}
+
+	var runs []runGroup
+
+	start := time.Now()
+
+	p.notify(NotifyStarted, nil, 0, nil)
 
-	batchCmd = append(batchCmd, p.Settings.Ansible.Version())
+	defer func() { p.report(start, err, runs) }()
 
 	if p.Settings.PrivateKey != "" {
 		p.Settings.Ansible.PrivateKeyFile, err = plugin_file.WriteTmpFile("privateKey", p.Settings.PrivateKey)
@@ -56,22 +170,113 @@ func (p *Plugin) Execute() error {
 		defer os.Remove(p.Settings.Ansible.VaultPasswordFile)
 	}
 
-	if p.Settings.PythonRequirements != "" {
-		batchCmd = append(batchCmd, PipInstall(p.Settings.PythonRequirements))
+	if len(p.Settings.AnsibleConfig) > 0 {
+		p.Settings.Ansible.ConfigFile, err = p.writeAnsibleConfig()
+		if err != nil {
+			return err
+		}
+
+		defer os.Remove(p.Settings.Ansible.ConfigFile)
+	}
+
+	if len(p.Settings.ExtraVars) > 0 {
+		extraVarsFile, err := p.writeExtraVars()
+		if err != nil {
+			return err
+		}
+
+		defer os.Remove(extraVarsFile)
+
+		p.Settings.Ansible.ExtraVars = append(p.Settings.Ansible.ExtraVars, fmt.Sprintf("@%s", extraVarsFile))
+	}
+
+	venvCmds, venvCache, err := p.virtualenv()
+	if err != nil {
+		return err
+	}
+
+	if venvCache == nil && p.Settings.Python.Virtualenv != "" {
+		defer os.RemoveAll(p.Settings.Python.Virtualenv)
+	}
+
+	pipCmds, pipCache, err := p.pipInstall()
+	if err != nil {
+		return err
+	}
+
+	pipCmds = slices.Concat(venvCmds, pipCmds)
+
+	if err := p.runPhase(phasePip, func() error { return p.runCmds(pipCmds) }); err != nil {
+		return err
+	}
+
+	if err := p.checkAnsibleVersion(); err != nil {
+		return err
+	}
+
+	galaxyCmds, galaxyCache, err := p.galaxyInstall()
+	if err != nil {
+		return err
+	}
+
+	if err := p.runPhase(phaseGalaxy, func() error { return p.runCmds(galaxyCmds) }); err != nil {
+		return err
+	}
+
+	for _, entry := range []*CacheEntry{venvCache, pipCache, galaxyCache} {
+		if entry == nil || entry.Hit {
+			continue
+		}
+
+		if err := entry.Save(); err != nil {
+			return fmt.Errorf("failed to save cache entry: %w", err)
+		}
+	}
+
+	if len(p.Settings.Ansible.GalaxyRequirementFiles()) > 0 {
+		if err := p.logGalaxyInstalled(); err != nil {
+			return err
+		}
 	}
 
-	if p.Settings.Ansible.GalaxyRequirements != "" {
-		batchCmd = append(batchCmd, p.Settings.Ansible.GalaxyInstall())
+	runs = p.planRuns()
+
+	if err := p.checkSafeMode(runs); err != nil {
+		return err
 	}
 
-	batchCmd = append(batchCmd, p.Settings.Ansible.Play())
+	if p.Settings.LockBackend != "" {
+		var release func()
+
+		if err := p.runPhase(phaseLock, func() (err error) {
+			release, err = p.acquireLock()
+
+			return err
+		}); err != nil {
+			return err
+		}
 
+		defer release()
+	}
+
+	if p.needsApproval(runs) {
+		if err := p.runPhase(phaseApproval, func() error { return p.requestApproval(runs) }); err != nil {
+			return err
+		}
+	}
+
+	return p.runWithHooks(func() error {
+		return p.runPhase(phasePlay, func() error { return errors.Join(p.runPlays(runs), p.lockErr()) })
+	})
+}
+
+func (p *Plugin) runCmds(batchCmd []*plugin_exec.Cmd) error {
 	for _, cmd := range batchCmd {
 		if cmd == nil {
 			continue
 		}
 
-		cmd.Env = append(os.Environ(), "ANSIBLE_FORCE_COLOR=1")
+		cmd.Env = p.environ()
 
 		if err := cmd.Run(); err != nil {
 			return err
@@ -80,3 +285,16 @@ func (p *Plugin) Execute() error {
 
 	return nil
 }
+
+func requireDir(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrNotADirectory, path)
+	}
+
+	return nil
+}
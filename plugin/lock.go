@@ -0,0 +1,121 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/lock"
+)
+
+const (
+	LockBackendFile = "file"
+	LockBackendHTTP = "http"
+
+	lockRequestTimeout = 30 * time.Second
+)
+
+var (
+	ErrLockBackendInvalid = errors.New("invalid lock backend")
+	ErrLockPathRequired   = errors.New("lock path is required for the file lock backend")
+	ErrLockURLRequired    = errors.New("lock url is required for the http lock backend")
+)
+
+func (p *Plugin) validateLock() error {
+	switch p.Settings.LockBackend {
+	case LockBackendFile:
+		if p.Settings.LockPath == "" {
+			return ErrLockPathRequired
+		}
+	case LockBackendHTTP:
+		if p.Settings.LockURL == "" {
+			return ErrLockURLRequired
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrLockBackendInvalid, p.Settings.LockBackend)
+	}
+
+	return nil
+}
+
+// acquireLock waits for the deployment lock and returns a function to release it. The lock
+// is refreshed until it is released; if it is lost, the run fails, see lockErr.
+func (p *Plugin) acquireLock() (func(), error) {
+	var backend lock.Backend = &lock.FileBackend{Dir: p.Settings.LockPath}
+	if p.Settings.LockBackend == LockBackendHTTP {
+		backend = &lock.HTTPBackend{
+			URL:    p.Settings.LockURL,
+			Client: &http.Client{Timeout: lockRequestTimeout},
+		}
+	}
+
+	name := p.Settings.metadata.Author
+	if hostname, err := os.Hostname(); name == "" && err == nil {
+		name = hostname
+	}
+
+	holder, err := lock.NewHolder(p.lockKey(), name, p.Settings.metadata.PipelineURL)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := lock.Options{
+		Timeout: p.Settings.LockTimeout,
+		TTL:     p.Settings.LockTTL,
+	}
+
+	if err := lock.Acquire(context.Background(), backend, holder, opts); err != nil {
+		return nil, err
+	}
+
+	lost, stop := lock.KeepAlive(backend, holder)
+	p.Settings.lockCtx = lost
+
+	return func() {
+		stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), lockRequestTimeout)
+		defer cancel()
+
+		if err := backend.Release(ctx, holder); err != nil {
+			log.Warn().Err(err).Str("key", holder.Key).Msg("failed to release lock")
+
+			return
+		}
+
+		log.Info().Str("key", holder.Key).Msg("lock released")
+	}, nil
+}
+
+// lockErr returns the error if the deployment lock was taken over by someone else while
+// it was held. Remaining playbook runs are skipped and the run fails in that case.
+func (p *Plugin) lockErr() error {
+	if p.Settings.lockCtx == nil {
+		return nil
+	}
+
+	if err := context.Cause(p.Settings.lockCtx); errors.Is(err, lock.ErrLockLost) {
+		return err
+	}
+
+	return nil
+}
+
+// lockKey returns the configured lock key or a key derived from the inventories and limit.
+func (p *Plugin) lockKey() string {
+	if p.Settings.LockKey != "" {
+		return p.Settings.LockKey
+	}
+
+	key := strings.Join(p.Settings.Ansible.Inventories, ",")
+	if p.Settings.Ansible.Limit != "" {
+		key = fmt.Sprintf("%s|%s", key, p.Settings.Ansible.Limit)
+	}
+
+	return key
+}
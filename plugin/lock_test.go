@@ -0,0 +1,157 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/lock"
+)
+
+func TestValidateLock(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *Settings
+		wantErr  error
+	}{
+		{
+			name:     "file backend",
+			settings: &Settings{LockBackend: LockBackendFile, LockPath: "/shared/locks"},
+		},
+		{
+			name:     "file backend without path",
+			settings: &Settings{LockBackend: LockBackendFile},
+			wantErr:  ErrLockPathRequired,
+		},
+		{
+			name:     "http backend without url",
+			settings: &Settings{LockBackend: LockBackendHTTP},
+			wantErr:  ErrLockURLRequired,
+		},
+		{
+			name:     "unknown backend",
+			settings: &Settings{LockBackend: "redis"},
+			wantErr:  ErrLockBackendInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Plugin{Settings: tt.settings}).validateLock()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLockKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *Settings
+		want     string
+	}{
+		{
+			name:     "inventories",
+			settings: &Settings{Ansible: ansible.Ansible{Inventories: []string{"prod", "shared"}}},
+			want:     "prod,shared",
+		},
+		{
+			name: "inventories with limit",
+			settings: &Settings{
+				Ansible: ansible.Ansible{Inventories: []string{"prod"}, Limit: "web"},
+			},
+			want: "prod|web",
+		},
+		{
+			name: "override",
+			settings: &Settings{
+				LockKey: "production",
+				Ansible: ansible.Ansible{Inventories: []string{"prod"}},
+			},
+			want: "production",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, (&Plugin{Settings: tt.settings}).lockKey())
+		})
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	dir := t.TempDir()
+	settings := &Settings{
+		LockBackend: LockBackendFile,
+		LockPath:    dir,
+		LockTimeout: time.Second,
+		metadata:    &metadata{Author: "octocat", PipelineURL: "https://ci.example.com/1"},
+		Ansible:     ansible.Ansible{Inventories: []string{"prod"}},
+	}
+	p := &Plugin{Settings: settings}
+
+	release, err := p.acquireLock()
+	require.NoError(t, err)
+
+	backend := &lock.FileBackend{Dir: dir}
+	other, err := lock.NewHolder("prod", "other", "")
+	require.NoError(t, err)
+
+	holder, err := backend.TryAcquire(t.Context(), other)
+	require.NoError(t, err)
+	require.NotNil(t, holder)
+	assert.Equal(t, "octocat", holder.Name)
+
+	release()
+
+	holder, err = backend.TryAcquire(t.Context(), other)
+	require.NoError(t, err)
+	assert.Nil(t, holder)
+}
+
+func TestLockLost(t *testing.T) {
+	dir := t.TempDir()
+	p := &Plugin{Settings: &Settings{
+		LockBackend: LockBackendFile,
+		LockPath:    dir,
+		LockTimeout: time.Second,
+		LockTTL:     100 * time.Millisecond,
+		metadata:    &metadata{Author: "octocat"},
+		Ansible:     ansible.Ansible{Inventories: []string{"prod"}},
+	}}
+
+	release, err := p.acquireLock()
+	require.NoError(t, err)
+
+	defer release()
+
+	assert.NoError(t, p.lockErr())
+
+	// Another pipeline took over the lock, e.g. after the refresh was delayed.
+	backend := &lock.FileBackend{Dir: dir}
+	other, err := lock.NewHolder("prod", "other", "")
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NoError(t, os.Remove(filepath.Join(dir, entries[0].Name())))
+	require.NoError(t, lock.Acquire(t.Context(), backend, other, lock.Options{Timeout: time.Second, TTL: time.Hour}))
+
+	assert.Eventually(t, func() bool { return p.lockErr() != nil }, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, p.lockErr(), lock.ErrLockLost)
+
+	group := runGroup{{ansible: ansible.Ansible{Playbooks: []string{"site.yml"}}}}
+	p.runGroup(group, &sync.Mutex{})
+	assert.True(t, group[0].skipped)
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"os"
+)
+
+// metadata holds the Woodpecker build metadata of the current pipeline.
+type metadata struct {
+	Commit       string
+	Branch       string
+	Tag          string
+	Build        string
+	PipelineURL  string
+	Author       string
+	Event        string
+	TargetBranch string
+	PrevCommit   string
+}
+
+func newMetadata() *metadata {
+	return &metadata{
+		Commit:       os.Getenv("CI_COMMIT_SHA"),
+		Branch:       os.Getenv("CI_COMMIT_BRANCH"),
+		Tag:          os.Getenv("CI_COMMIT_TAG"),
+		Build:        os.Getenv("CI_PIPELINE_NUMBER"),
+		PipelineURL:  os.Getenv("CI_PIPELINE_URL"),
+		Author:       os.Getenv("CI_COMMIT_AUTHOR"),
+		Event:        os.Getenv("CI_PIPELINE_EVENT"),
+		TargetBranch: os.Getenv("CI_COMMIT_TARGET_BRANCH"),
+		PrevCommit:   os.Getenv("CI_PREV_COMMIT_SHA"),
+	}
+}
+
+// vars returns the metadata as extra vars dict.
+func (m *metadata) vars() map[string]any {
+	return map[string]any{
+		"commit_sha":   m.Commit,
+		"branch":       m.Branch,
+		"tag":          m.Tag,
+		"build_number": m.Build,
+		"pipeline_url": m.PipelineURL,
+		"author":       m.Author,
+	}
+}
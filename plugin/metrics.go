@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/metrics"
+)
+
+var ErrMetricsLabelReserved = errors.New("metrics label name is reserved")
+
+// reservedMetricsLabels are the label names of the job, the default grouping labels and
+// the labels of the samples, which can not be used as additional grouping labels.
+//
+//nolint:gochecknoglobals
+var reservedMetricsLabels = []string{"job", "inventory", "playbook", "phase", "status", "host"}
+
+func (p *Plugin) validateMetrics() error {
+	for _, name := range sortedKeys(p.Settings.MetricsLabels) {
+		if slices.Contains(reservedMetricsLabels, name) {
+			return fmt.Errorf("%w: %s", ErrMetricsLabelReserved, name)
+		}
+	}
+
+	return nil
+}
+
+// pushMetrics pushes the metrics of the run to the Pushgateway.
+func (p *Plugin) pushMetrics(start, end time.Time, runErr error, groups []runGroup) error {
+	pusher := &metrics.Pusher{
+		URL:      p.Settings.MetricsURL,
+		Job:      p.Settings.MetricsJob,
+		Grouping: p.metricsGrouping(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	return pusher.Push(ctx, p.runMetrics(start, end, runErr, groups))
+}
+
+// metricsGrouping returns the grouping labels of the pushed metrics. Runs against other
+// inventories or playbooks are grouped separately, so their pushes do not replace each
+// other. The inventories and playbooks are left to the playbook metrics labels if they
+// differ between the runs in matrix or per-playbook mode.
+func (p *Plugin) metricsGrouping() []metrics.Label {
+	grouping := make([]metrics.Label, 0, len(p.Settings.MetricsLabels))
+
+	for _, name := range sortedKeys(p.Settings.MetricsLabels) {
+		grouping = append(grouping, metrics.Label{Name: name, Value: p.Settings.MetricsLabels[name]})
+	}
+
+	if !p.Settings.InventoryMatrix {
+		grouping = append(grouping, metrics.Label{
+			Name: "inventory", Value: strings.Join(p.Settings.Ansible.Inventories, ","),
+		})
+	}
+
+	if !p.Settings.PerPlaybook {
+		grouping = append(grouping, metrics.Label{
+			Name: "playbook", Value: strings.Join(p.Settings.Ansible.Playbooks, ","),
+		})
+	}
+
+	return grouping
+}
+
+// runMetrics returns the metrics of the run. Playbook metrics are labeled with the
+// inventories and playbooks of each ansible-playbook run.
+func (p *Plugin) runMetrics(start, end time.Time, runErr error, groups []runGroup) *metrics.Set {
+	set := &metrics.Set{}
+
+	set.Gauge("ansible_run_duration_seconds", "Duration of the run in seconds.", end.Sub(start).Seconds())
+	set.Gauge("ansible_run_success", "Whether the run succeeded.", boolValue(runErr == nil))
+	set.Gauge("ansible_run_last_timestamp_seconds", "Time the run finished as unix timestamp.",
+		float64(end.Unix()))
+
+	for _, ph := range p.Settings.phases {
+		set.Gauge("ansible_phase_duration_seconds", "Duration of the phase in seconds.",
+			ph.end.Sub(ph.start).Seconds(), metrics.Label{Name: "phase", Value: ph.name})
+	}
+
+	for _, group := range groups {
+		for _, run := range group {
+			if run.skipped {
+				continue
+			}
+
+			labels := []metrics.Label{
+				{Name: "inventory", Value: strings.Join(run.ansible.Inventories, ",")},
+				{Name: "playbook", Value: strings.Join(run.ansible.Playbooks, ",")},
+			}
+
+			set.Gauge("ansible_playbook_duration_seconds", "Duration of the playbook run in seconds.",
+				run.duration.Seconds(), labels...)
+			set.Gauge("ansible_playbook_success", "Whether the playbook run succeeded.",
+				boolValue(run.err == nil), labels...)
+			set.Gauge("ansible_hosts_changed", "Number of hosts with changed tasks.",
+				float64(len(run.recap.Changed())), labels...)
+
+			recapMetrics(set, &run.recap, labels)
+		}
+	}
+
+	return set
+}
+
+func recapMetrics(set *metrics.Set, recap *ansible.Recap, labels []metrics.Label) {
+	for _, s := range recap.Hosts {
+		for _, result := range []struct {
+			status string
+			count  int
+		}{
+			{ansible.StatusOk, s.Ok},
+			{ansible.StatusChanged, s.Changed},
+			{ansible.StatusUnreachable, s.Unreachable},
+			{ansible.StatusFailed, s.Failed},
+			{ansible.StatusSkipped, s.Skipped},
+			{ansible.StatusRescued, s.Rescued},
+			{ansible.StatusIgnored, s.Ignored},
+		} {
+			set.Gauge("ansible_task_results", "Number of task results by status.", float64(result.count),
+				slices.Concat(labels, []metrics.Label{{Name: "status", Value: result.status}})...)
+		}
+
+		set.Gauge("ansible_host_failures", "Number of failed tasks of the host, including unreachable.",
+			float64(s.Failed+s.Unreachable), slices.Concat(labels, []metrics.Label{{Name: "host", Value: s.Host}})...)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
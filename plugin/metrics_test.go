@@ -0,0 +1,142 @@
+package plugin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/metrics"
+)
+
+func TestRunMetrics(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+
+	p := &Plugin{Settings: &Settings{
+		phases: []*phase{
+			{name: phasePip, start: start, end: start.Add(20 * time.Second)},
+			{name: phasePlay, start: start.Add(20 * time.Second), end: start.Add(80 * time.Second)},
+		},
+	}}
+
+	groups := []runGroup{{
+		{
+			ansible:  ansible.Ansible{Inventories: []string{"prod"}, Playbooks: []string{"site.yml"}},
+			duration: 60 * time.Second,
+			err:      ErrPlayFailed,
+			recap: ansible.Recap{Hosts: []*ansible.HostStats{
+				{Host: "web1", Ok: 3, Changed: 1},
+				{Host: "web2", Ok: 1, Failed: 1},
+			}},
+		},
+		{
+			ansible: ansible.Ansible{Inventories: []string{"prod"}, Playbooks: []string{"cleanup.yml"}},
+			skipped: true,
+		},
+	}}
+
+	var b strings.Builder
+
+	_, err := p.runMetrics(start, start.Add(90*time.Second), errors.New("exit status 2"), groups).WriteTo(&b)
+	require.NoError(t, err)
+
+	out := b.String()
+
+	for _, want := range []string{
+		"ansible_run_duration_seconds 90\n",
+		"ansible_run_success 0\n",
+		"ansible_run_last_timestamp_seconds 1.70000009e+09\n",
+		`ansible_phase_duration_seconds{phase="pip"} 20` + "\n",
+		`ansible_phase_duration_seconds{phase="play"} 60` + "\n",
+		`ansible_playbook_duration_seconds{inventory="prod",playbook="site.yml"} 60` + "\n",
+		`ansible_playbook_success{inventory="prod",playbook="site.yml"} 0` + "\n",
+		`ansible_hosts_changed{inventory="prod",playbook="site.yml"} 1` + "\n",
+		`ansible_task_results{inventory="prod",playbook="site.yml",status="ok"} 4` + "\n",
+		`ansible_task_results{inventory="prod",playbook="site.yml",status="changed"} 1` + "\n",
+		`ansible_task_results{inventory="prod",playbook="site.yml",status="failed"} 1` + "\n",
+		`ansible_host_failures{inventory="prod",playbook="site.yml",host="web1"} 0` + "\n",
+		`ansible_host_failures{inventory="prod",playbook="site.yml",host="web2"} 1` + "\n",
+	} {
+		assert.Contains(t, out, want)
+	}
+
+	assert.NotContains(t, out, "cleanup.yml")
+}
+
+func TestMetricsGrouping(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *Settings
+		want     []metrics.Label
+	}{
+		{
+			name: "inventory and playbooks",
+			settings: &Settings{
+				Ansible: ansible.Ansible{Inventories: []string{"prod", "dr"}, Playbooks: []string{"site.yml"}},
+			},
+			want: []metrics.Label{{Name: "inventory", Value: "prod,dr"}, {Name: "playbook", Value: "site.yml"}},
+		},
+		{
+			name: "matrix and per playbook",
+			settings: &Settings{
+				Ansible:         ansible.Ansible{Inventories: []string{"prod"}, Playbooks: []string{"site.yml"}},
+				InventoryMatrix: true,
+				PerPlaybook:     true,
+			},
+			want: []metrics.Label{},
+		},
+		{
+			name: "configured labels",
+			settings: &Settings{
+				Ansible:       ansible.Ansible{Inventories: []string{"prod"}, Playbooks: []string{"site.yml"}},
+				MetricsLabels: map[string]string{"repo": "infra/deploy", "environment": "production"},
+			},
+			want: []metrics.Label{
+				{Name: "environment", Value: "production"},
+				{Name: "repo", Value: "infra/deploy"},
+				{Name: "inventory", Value: "prod"},
+				{Name: "playbook", Value: "site.yml"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: tt.settings}
+
+			assert.Equal(t, tt.want, p.metricsGrouping())
+		})
+	}
+}
+
+func TestValidateMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr error
+	}{
+		{name: "without labels"},
+		{name: "custom labels", labels: map[string]string{"repo": "infra/deploy", "environment": "production"}},
+		{name: "grouping label", labels: map[string]string{"inventory": "production"}, wantErr: ErrMetricsLabelReserved},
+		{name: "sample label", labels: map[string]string{"host": "web1"}, wantErr: ErrMetricsLabelReserved},
+		{name: "job label", labels: map[string]string{"job": "deploy"}, wantErr: ErrMetricsLabelReserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{MetricsLabels: tt.labels}}
+
+			err := p.validateMetrics()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
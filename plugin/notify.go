@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+const (
+	NotifyStarted = "started"
+	NotifySuccess = "success"
+	NotifyFailure = "failure"
+
+	notifyTimeout = 30 * time.Second
+)
+
+var (
+	ErrNotificationInvalid = errors.New("invalid notification")
+	ErrNotificationFailed  = errors.New("notification failed")
+)
+
+// Notification is a webhook that is called when the run starts or ends.
+type Notification struct {
+	URL      string            `yaml:"url"`
+	Events   stringList        `yaml:"events"`
+	Template string            `yaml:"template"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// notifyPayload is the JSON payload of notifications and the data of notification
+// templates.
+type notifyPayload struct {
+	Status       string               `json:"status"`
+	Error        string               `json:"error,omitempty"`
+	Duration     float64              `json:"duration"`
+	Commit       string               `json:"commit"`
+	Branch       string               `json:"branch"`
+	Tag          string               `json:"tag"`
+	Author       string               `json:"author"`
+	Pipeline     string               `json:"pipeline"`
+	Environment  string               `json:"environment"`
+	Inventories  []string             `json:"inventories"`
+	Playbooks    []string             `json:"playbooks"`
+	HostsChanged []string             `json:"hosts_changed"`
+	HostsFailed  []string             `json:"hosts_failed"`
+	Hosts        []*ansible.HostStats `json:"hosts"`
+}
+
+// validateNotifications checks the notification URLs and events.
+func (p *Plugin) validateNotifications() error {
+	for i, n := range p.Settings.Notifications {
+		if n.URL == "" {
+			return fmt.Errorf("%w: url of notification %d is required", ErrNotificationInvalid, i)
+		}
+
+		for _, event := range n.Events {
+			if !slices.Contains([]string{NotifyStarted, NotifySuccess, NotifyFailure}, event) {
+				return fmt.Errorf("%w: unknown event %q of notification %d", ErrNotificationInvalid, event, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// notify sends the notifications for the status. The outcome of the playbook runs is
+// only available at the end of the run. Failed notifications are logged but do not
+// fail the run.
+func (p *Plugin) notify(status string, runErr error, duration time.Duration, groups []runGroup) {
+	payload := p.notifyPayload(status, runErr, duration, groups)
+
+	for _, n := range p.Settings.Notifications {
+		if len(n.Events) > 0 && !slices.Contains(n.Events, status) {
+			continue
+		}
+
+		if err := n.send(payload); err != nil {
+			log.Warn().Err(err).Str("status", status).Msg("failed to send notification")
+		}
+	}
+}
+
+func (p *Plugin) notifyPayload(status string, runErr error, duration time.Duration, groups []runGroup) *notifyPayload {
+	recap := &ansible.Recap{}
+
+	for _, group := range groups {
+		for _, run := range group {
+			recap.Merge(&run.recap)
+		}
+	}
+
+	payload := &notifyPayload{
+		Status:       status,
+		Duration:     duration.Seconds(),
+		Environment:  p.Settings.environment,
+		Inventories:  p.Settings.Ansible.Inventories,
+		Playbooks:    p.Settings.Ansible.Playbooks,
+		HostsChanged: recap.Changed(),
+		HostsFailed:  recap.Failed(),
+		Hosts:        recap.Hosts,
+	}
+
+	if runErr != nil {
+		payload.Error = runErr.Error()
+	}
+
+	if m := p.Settings.metadata; m != nil {
+		payload.Commit, payload.Branch, payload.Tag = m.Commit, m.Branch, m.Tag
+		payload.Author, payload.Pipeline = m.Author, m.PipelineURL
+	}
+
+	if payload.Hosts == nil {
+		payload.Hosts = make([]*ansible.HostStats, 0)
+	}
+
+	return payload
+}
+
+// send posts the payload to the notification URL, rendered with the template if set.
+func (n *Notification) send(payload *notifyPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if n.Template != "" {
+		rendered, err := renderTemplate("notification", n.Template, payload)
+		if err != nil {
+			return err
+		}
+
+		body = []byte(rendered)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range n.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotificationFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:mnd
+
+		return fmt.Errorf("%w: unexpected status %s: %s", ErrNotificationFailed, resp.Status,
+			strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestValidateNotifications(t *testing.T) {
+	tests := []struct {
+		name          string
+		notifications []*Notification
+		wantErr       error
+	}{
+		{
+			name:          "valid",
+			notifications: []*Notification{{URL: "https://chat.example.com", Events: stringList{NotifyFailure}}},
+		},
+		{
+			name:          "missing url",
+			notifications: []*Notification{{Events: stringList{NotifyFailure}}},
+			wantErr:       ErrNotificationInvalid,
+		},
+		{
+			name:          "unknown event",
+			notifications: []*Notification{{URL: "https://chat.example.com", Events: stringList{"end"}}},
+			wantErr:       ErrNotificationInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Plugin{Settings: &Settings{Notifications: tt.notifications}}).validateNotifications()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies = make(map[string][]string)
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		bodies[r.URL.Path] = append(bodies[r.URL.Path], string(body))
+
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	p := &Plugin{Settings: &Settings{
+		Notifications: []*Notification{
+			{URL: server.URL + "/json"},
+			{
+				URL:      server.URL + "/chat",
+				Events:   stringList{NotifyFailure},
+				Template: `{"text": {{ printf "%s on %s: %s" .Status .Commit .Error | toJson }}}`,
+			},
+			{URL: server.URL + "/broken"},
+		},
+		metadata: &metadata{Commit: "a1b2c3"},
+		Ansible:  ansible.Ansible{Inventories: []string{"prod"}, Playbooks: []string{"site.yml"}},
+	}}
+
+	groups := []runGroup{{
+		{recap: ansible.Recap{Hosts: []*ansible.HostStats{{Host: "web1", Ok: 2, Changed: 1}}}},
+		{recap: ansible.Recap{Hosts: []*ansible.HostStats{{Host: "web2", Ok: 1, Failed: 1}}}},
+	}}
+
+	p.notify(NotifyStarted, nil, 0, nil)
+	p.notify(NotifyFailure, errors.New(`exit "2"`), 90*time.Second, groups)
+
+	require.Len(t, bodies["/json"], 2)
+	assert.JSONEq(t, `{
+		"status": "started", "duration": 0, "commit": "a1b2c3", "branch": "", "tag": "", "author": "",
+		"pipeline": "", "environment": "", "inventories": ["prod"], "playbooks": ["site.yml"],
+		"hosts_changed": [], "hosts_failed": [], "hosts": []
+	}`, bodies["/json"][0])
+	assert.JSONEq(t, `{
+		"status": "failure", "error": "exit \"2\"", "duration": 90, "commit": "a1b2c3", "branch": "", "tag": "",
+		"author": "", "pipeline": "", "environment": "", "inventories": ["prod"], "playbooks": ["site.yml"],
+		"hosts_changed": ["web1"], "hosts_failed": ["web2"],
+		"hosts": [
+			{"host": "web1", "ok": 2, "changed": 1, "unreachable": 0, "failed": 0, "skipped": 0, "rescued": 0, "ignored": 0},
+			{"host": "web2", "ok": 1, "changed": 0, "unreachable": 0, "failed": 1, "skipped": 0, "rescued": 0, "ignored": 0}
+		]
+	}`, bodies["/json"][1])
+	assert.Equal(t, []string{`{"text": "failure on a1b2c3: exit \"2\""}`}, bodies["/chat"])
+	assert.Len(t, bodies["/broken"], 2)
+}
@@ -1,9 +1,12 @@
 package plugin
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/python"
 	plugin_base "github.com/thegeeklab/wp-plugin-go/v6/plugin"
 	"github.com/urfave/cli/v3"
 )
@@ -16,12 +19,74 @@ type Plugin struct {
 	Settings *Settings
 }
 
-// Settings for the Plugin.
+// Settings for the Plugin. The environment variables of settings tagged as secret are never
+// inherited by the ansible processes and hooks, see secretEnv.
 type Settings struct {
-	PythonRequirements string
-	PrivateKey         string
-	VaultPassword      string
-	Ansible            ansible.Ansible
+	PrivateKey              string `secret:"true"`
+	VaultPassword           string `secret:"true"`
+	CacheDir                string
+	Offline                 bool
+	PythonWheelhouse        string
+	PythonVirtualenv        bool
+	AnsibleVersion          string
+	MinAnsibleVersion       string
+	MaxAnsibleVersion       string
+	AnsibleConfig           map[string]map[string]any
+	AnsibleConfigMerge      bool
+	AnsibleEnv              map[string]any
+	Env                     map[string]string
+	EnvAllow                []string
+	EnvDeny                 []string
+	ForceColor              bool
+	ExtraVars               map[string]any
+	CIVars                  bool
+	CIVarsNamespace         string
+	Templates               bool
+	Environments            []*Environment
+	PullRequestApply        bool
+	InventoryMatrix         bool
+	InventoryMatrixParallel int
+	PerPlaybook             bool
+	PlaybookOverrides       playbookOverrides
+	ContinueOnError         bool
+	ChangedOnly             bool
+	ChangedOnlyBase         string
+	ValidateReferences      bool
+	DependencyGraph         string
+	DependencyGraphFormat   string
+	PreCommands             []string
+	PostCommands            []string
+	PostCommandsOnSuccess   []string
+	PostCommandsOnFailure   []string
+	LockBackend             string
+	LockPath                string
+	LockURL                 string `secret:"true"`
+	LockKey                 string
+	LockTimeout             time.Duration
+	LockTTL                 time.Duration
+	ApprovalURL             string `secret:"true"`
+	ApprovalToken           string `secret:"true"`
+	ApprovalTimeout         time.Duration
+	Notifications           []*Notification   `secret:"true"`
+	OTelEndpoint            string            `secret:"true"`
+	OTelHeaders             map[string]string `secret:"true"`
+	OTelServiceName         string
+	MetricsURL              string `secret:"true"`
+	MetricsJob              string
+	MetricsLabels           map[string]string
+	Profile                 bool
+	ProfileLimit            int
+	ProfileOutput           string
+	Python                  python.Python
+	Ansible                 ansible.Ansible
+
+	installAnsible bool
+	metadata       *metadata
+	environment    string
+	safeMode       bool
+	skipRun        bool
+	phases         []*phase
+	lockCtx        context.Context
 }
 
 func New(e plugin_base.ExecuteFunc, build ...string) *Plugin {
@@ -57,11 +122,132 @@ func New(e plugin_base.ExecuteFunc, build ...string) *Plugin {
 // Flags returns a slice of CLI flags for the plugin.
 func Flags(settings *Settings, category string) []cli.Flag {
 	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "ansible-version",
+			Usage:       "ansible-core version to install if it differs from the bundled version",
+			Sources:     cli.EnvVars("PLUGIN_ANSIBLE_VERSION"),
+			Destination: &settings.AnsibleVersion,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "min-ansible-version",
+			Usage:       "minimum ansible-core version required",
+			Sources:     cli.EnvVars("PLUGIN_MIN_ANSIBLE_VERSION"),
+			Destination: &settings.MinAnsibleVersion,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "max-ansible-version",
+			Usage:       "maximum ansible-core version allowed",
+			Sources:     cli.EnvVars("PLUGIN_MAX_ANSIBLE_VERSION"),
+			Destination: &settings.MaxAnsibleVersion,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "ansible-config",
+			Usage:    "ansible.cfg options as a map of sections to options",
+			Sources:  cli.EnvVars("PLUGIN_ANSIBLE_CONFIG"),
+			Value:    newYAMLValue(&settings.AnsibleConfig),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:        "ansible-config-merge",
+			Usage:       "merge ansible-config options into the existing ansible.cfg",
+			Sources:     cli.EnvVars("PLUGIN_ANSIBLE_CONFIG_MERGE"),
+			Value:       true,
+			Destination: &settings.AnsibleConfigMerge,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "ansible-env",
+			Usage:    "ansible options passed as `ANSIBLE_<KEY>` environment variables",
+			Sources:  cli.EnvVars("PLUGIN_ANSIBLE_ENV"),
+			Value:    newYAMLValue(&settings.AnsibleEnv),
+			Category: category,
+		},
+		&cli.GenericFlag{
+			Name:     "env",
+			Usage:    "additional environment variables for the ansible processes",
+			Sources:  cli.EnvVars("PLUGIN_ENV"),
+			Value:    newYAMLValue(&settings.Env),
+			Category: category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "env-allow",
+			Usage:       "patterns of environment variables inherited by the ansible processes",
+			Sources:     cli.EnvVars("PLUGIN_ENV_ALLOW"),
+			Destination: &settings.EnvAllow,
+			Category:    category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "env-deny",
+			Usage:       "patterns of environment variables not inherited by the ansible processes",
+			Sources:     cli.EnvVars("PLUGIN_ENV_DENY"),
+			Destination: &settings.EnvDeny,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "force-color",
+			Usage:       "force colored ansible output",
+			Sources:     cli.EnvVars("PLUGIN_FORCE_COLOR"),
+			Value:       true,
+			Destination: &settings.ForceColor,
+			Category:    category,
+		},
 		&cli.StringFlag{
 			Name:        "python-requirements",
 			Usage:       "path to python requirements file",
 			Sources:     cli.EnvVars("PLUGIN_PYTHON_REQUIREMENTS"),
-			Destination: &settings.PythonRequirements,
+			Destination: &settings.Python.Requirements,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "python-constraints",
+			Usage:       "path to python constraints file",
+			Sources:     cli.EnvVars("PLUGIN_PYTHON_CONSTRAINTS"),
+			Destination: &settings.Python.Constraints,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "python-virtualenv",
+			Usage:       "install python requirements into an isolated virtual environment",
+			Sources:     cli.EnvVars("PLUGIN_PYTHON_VIRTUALENV"),
+			Destination: &settings.PythonVirtualenv,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "python-system-site-packages",
+			Usage:       "give the virtual environment access to the system site packages",
+			Sources:     cli.EnvVars("PLUGIN_PYTHON_SYSTEM_SITE_PACKAGES"),
+			Destination: &settings.Python.SystemSitePackages,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "cache-dir",
+			Usage:       "path to a persistent directory used to cache galaxy and python dependencies",
+			Sources:     cli.EnvVars("PLUGIN_CACHE_DIR"),
+			Destination: &settings.CacheDir,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "offline",
+			Usage:       "install galaxy and python dependencies from vendored artifacts only",
+			Sources:     cli.EnvVars("PLUGIN_OFFLINE"),
+			Destination: &settings.Offline,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "python-wheelhouse",
+			Usage:       "path to a directory containing vendored python packages",
+			Sources:     cli.EnvVars("PLUGIN_PYTHON_WHEELHOUSE"),
+			Destination: &settings.PythonWheelhouse,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "galaxy-vendor-path",
+			Usage:       "path to a directory containing vendored galaxy collection artifacts and roles",
+			Sources:     cli.EnvVars("PLUGIN_GALAXY_VENDOR_PATH"),
+			Destination: &settings.Ansible.GalaxyVendorPath,
 			Category:    category,
 		},
 		&cli.StringFlag{
@@ -71,11 +257,31 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.Ansible.GalaxyRequirements,
 			Category:    category,
 		},
+		&cli.StringFlag{
+			Name:        "galaxy-collection-requirements",
+			Usage:       "path to a separate galaxy collection requirements file",
+			Sources:     cli.EnvVars("PLUGIN_GALAXY_COLLECTION_REQUIREMENTS"),
+			Destination: &settings.Ansible.GalaxyCollectionRequirements,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "galaxy-roles-path",
+			Usage:       "path to the directory containing galaxy roles",
+			Sources:     cli.EnvVars("PLUGIN_GALAXY_ROLES_PATH"),
+			Destination: &settings.Ansible.GalaxyRolesPath,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "galaxy-collections-path",
+			Usage:       "path to the directory containing galaxy collections",
+			Sources:     cli.EnvVars("PLUGIN_GALAXY_COLLECTIONS_PATH"),
+			Destination: &settings.Ansible.GalaxyCollectionsPath,
+			Category:    category,
+		},
 		&cli.StringSliceFlag{
 			Name:        "inventory",
 			Usage:       "path to inventory file",
 			Sources:     cli.EnvVars("PLUGIN_INVENTORY", "PLUGIN_INVENTORIES"),
-			Required:    true,
 			Destination: &settings.Ansible.Inventories,
 			Category:    category,
 		},
@@ -87,6 +293,13 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.Ansible.Playbooks,
 			Category:    category,
 		},
+		&cli.StringSliceFlag{
+			Name:        "playbook-exclude",
+			Usage:       "list of patterns of playbooks to exclude",
+			Sources:     cli.EnvVars("PLUGIN_PLAYBOOK_EXCLUDE", "PLUGIN_PLAYBOOK_EXCLUDES"),
+			Destination: &settings.Ansible.PlaybookExcludes,
+			Category:    category,
+		},
 		&cli.StringFlag{
 			Name:        "limit",
 			Usage:       "limit selected hosts to an additional pattern",
@@ -115,11 +328,286 @@ func Flags(settings *Settings, category string) []cli.Flag {
 			Destination: &settings.Ansible.Tags,
 			Category:    category,
 		},
+		&cli.GenericFlag{
+			Name:     "extra-vars",
+			Usage:    "set additional variables as `key=value`, `@file` or a YAML/JSON object",
+			Sources:  cli.EnvVars("PLUGIN_EXTRA_VARS", "ANSIBLE_EXTRA_VARS"),
+			Value:    newExtraVarsValue(&settings.Ansible.ExtraVars, &settings.ExtraVars),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:        "ci-vars",
+			Usage:       "add the build metadata as extra vars",
+			Sources:     cli.EnvVars("PLUGIN_CI_VARS"),
+			Destination: &settings.CIVars,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "ci-vars-namespace",
+			Usage:       "name of the extra var holding the build metadata",
+			Sources:     cli.EnvVars("PLUGIN_CI_VARS_NAMESPACE"),
+			Value:       "ci",
+			Destination: &settings.CIVarsNamespace,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "templates",
+			Usage:       "render go templates with [[ ]] delimiters in inventory, limit, tags and extra-vars",
+			Sources:     cli.EnvVars("PLUGIN_TEMPLATES"),
+			Destination: &settings.Templates,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "environments",
+			Usage:    "list of environments with setting overrides selected by branch or tag patterns",
+			Sources:  cli.EnvVars("PLUGIN_ENVIRONMENTS"),
+			Value:    newYAMLValue(&settings.Environments),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:        "pull-request-apply",
+			Usage:       "disable the pull request safe mode and apply changes for pull requests",
+			Sources:     cli.EnvVars("PLUGIN_PULL_REQUEST_APPLY"),
+			Destination: &settings.PullRequestApply,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "inventory-matrix",
+			Usage:       "run the playbooks separately for each inventory",
+			Sources:     cli.EnvVars("PLUGIN_INVENTORY_MATRIX"),
+			Destination: &settings.InventoryMatrix,
+			Category:    category,
+		},
+		&cli.IntFlag{
+			Name:        "inventory-matrix-parallel",
+			Usage:       "maximum number of inventories to run in parallel in matrix mode",
+			Sources:     cli.EnvVars("PLUGIN_INVENTORY_MATRIX_PARALLEL"),
+			Value:       1,
+			Destination: &settings.InventoryMatrixParallel,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "per-playbook",
+			Usage:       "run each playbook separately in the declared order",
+			Sources:     cli.EnvVars("PLUGIN_PER_PLAYBOOK"),
+			Destination: &settings.PerPlaybook,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "playbook-overrides",
+			Usage:    "map of playbook patterns to tags, skip-tags, limit and check overrides in per-playbook mode",
+			Sources:  cli.EnvVars("PLUGIN_PLAYBOOK_OVERRIDES"),
+			Value:    newYAMLValue(&settings.PlaybookOverrides),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:        "continue-on-error",
+			Usage:       "continue with the remaining playbooks if a playbook failed in per-playbook mode",
+			Sources:     cli.EnvVars("PLUGIN_CONTINUE_ON_ERROR"),
+			Destination: &settings.ContinueOnError,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "changed-only",
+			Usage:       "only run playbooks depending on files changed by the commit or pull request",
+			Sources:     cli.EnvVars("PLUGIN_CHANGED_ONLY"),
+			Destination: &settings.ChangedOnly,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "changed-only-base",
+			Usage:       "git revision to detect changed files against",
+			Sources:     cli.EnvVars("PLUGIN_CHANGED_ONLY_BASE"),
+			Destination: &settings.ChangedOnlyBase,
+			Category:    category,
+		},
+		&cli.BoolFlag{
+			Name:        "validate-references",
+			Usage:       "fail if a role or file referenced by the playbooks does not exist",
+			Sources:     cli.EnvVars("PLUGIN_VALIDATE_REFERENCES"),
+			Destination: &settings.ValidateReferences,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "dependency-graph",
+			Usage:       "path to write the dependency graph of the playbooks to",
+			Sources:     cli.EnvVars("PLUGIN_DEPENDENCY_GRAPH"),
+			Destination: &settings.DependencyGraph,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "dependency-graph-format",
+			Usage:       "format of the dependency graph, either json or dot",
+			Sources:     cli.EnvVars("PLUGIN_DEPENDENCY_GRAPH_FORMAT"),
+			Value:       GraphFormatJSON,
+			Destination: &settings.DependencyGraphFormat,
+			Category:    category,
+		},
 		&cli.StringSliceFlag{
-			Name:        "extra-vars",
-			Usage:       "set additional variables as `key=value`",
-			Sources:     cli.EnvVars("PLUGIN_EXTRA_VARS", "ANSIBLE_EXTRA_VARS"),
-			Destination: &settings.Ansible.ExtraVars,
+			Name:        "pre-commands",
+			Usage:       "shell commands to run before the playbooks",
+			Sources:     cli.EnvVars("PLUGIN_PRE_COMMANDS"),
+			Destination: &settings.PreCommands,
+			Category:    category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "post-commands",
+			Usage:       "shell commands to always run after the playbooks",
+			Sources:     cli.EnvVars("PLUGIN_POST_COMMANDS"),
+			Destination: &settings.PostCommands,
+			Category:    category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "post-commands-on-success",
+			Usage:       "shell commands to run after the playbooks succeeded",
+			Sources:     cli.EnvVars("PLUGIN_POST_COMMANDS_ON_SUCCESS"),
+			Destination: &settings.PostCommandsOnSuccess,
+			Category:    category,
+		},
+		&cli.StringSliceFlag{
+			Name:        "post-commands-on-failure",
+			Usage:       "shell commands to run after the playbooks failed",
+			Sources:     cli.EnvVars("PLUGIN_POST_COMMANDS_ON_FAILURE"),
+			Destination: &settings.PostCommandsOnFailure,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "lock-backend",
+			Usage:       "deployment lock backend, either file or http",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_BACKEND"),
+			Destination: &settings.LockBackend,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "lock-path",
+			Usage:       "path to a shared directory for lock files",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_PATH"),
+			Destination: &settings.LockPath,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "lock-url",
+			Usage:       "url of the http lock endpoint",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_URL"),
+			Destination: &settings.LockURL,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "lock-key",
+			Usage:       "lock key, defaults to the inventories and limit",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_KEY"),
+			Destination: &settings.LockKey,
+			Category:    category,
+		},
+		&cli.DurationFlag{
+			Name:        "lock-timeout",
+			Usage:       "maximum time to wait for the lock",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_TIMEOUT"),
+			Value:       10 * time.Minute, //nolint:mnd
+			Destination: &settings.LockTimeout,
+			Category:    category,
+		},
+		&cli.DurationFlag{
+			Name:        "lock-ttl",
+			Usage:       "time after which a lock is considered stale",
+			Sources:     cli.EnvVars("PLUGIN_LOCK_TTL"),
+			Value:       time.Hour,
+			Destination: &settings.LockTTL,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "approval-url",
+			Usage:       "url of an approval endpoint to confirm the changes of a check run before applying them",
+			Sources:     cli.EnvVars("PLUGIN_APPROVAL_URL"),
+			Destination: &settings.ApprovalURL,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "approval-token",
+			Usage:       "bearer token for the approval endpoint",
+			Sources:     cli.EnvVars("PLUGIN_APPROVAL_TOKEN"),
+			Destination: &settings.ApprovalToken,
+			Category:    category,
+		},
+		&cli.DurationFlag{
+			Name:        "approval-timeout",
+			Usage:       "maximum time to wait for the approval",
+			Sources:     cli.EnvVars("PLUGIN_APPROVAL_TIMEOUT"),
+			Value:       time.Hour,
+			Destination: &settings.ApprovalTimeout,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "notifications",
+			Usage:    "list of webhooks notified when the run starts and ends",
+			Sources:  cli.EnvVars("PLUGIN_NOTIFICATIONS"),
+			Value:    newYAMLValue(&settings.Notifications),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:        "otel-endpoint",
+			Usage:       "base url of an opentelemetry collector to export traces of the run via otlp/http",
+			Sources:     cli.EnvVars("PLUGIN_OTEL_ENDPOINT"),
+			Destination: &settings.OTelEndpoint,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "otel-headers",
+			Usage:    "headers sent to the opentelemetry collector",
+			Sources:  cli.EnvVars("PLUGIN_OTEL_HEADERS"),
+			Value:    newYAMLValue(&settings.OTelHeaders),
+			Category: category,
+		},
+		&cli.StringFlag{
+			Name:        "otel-service-name",
+			Usage:       "service name of the exported traces",
+			Sources:     cli.EnvVars("PLUGIN_OTEL_SERVICE_NAME"),
+			Value:       "wp-ansible",
+			Destination: &settings.OTelServiceName,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "metrics-url",
+			Usage:       "url of a prometheus pushgateway to push metrics of the run to",
+			Sources:     cli.EnvVars("PLUGIN_METRICS_URL"),
+			Destination: &settings.MetricsURL,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "metrics-job",
+			Usage:       "job name of the pushed metrics",
+			Sources:     cli.EnvVars("PLUGIN_METRICS_JOB"),
+			Value:       "wp-ansible",
+			Destination: &settings.MetricsJob,
+			Category:    category,
+		},
+		&cli.GenericFlag{
+			Name:     "metrics-labels",
+			Usage:    "grouping labels of the pushed metrics",
+			Sources:  cli.EnvVars("PLUGIN_METRICS_LABELS"),
+			Value:    newYAMLValue(&settings.MetricsLabels),
+			Category: category,
+		},
+		&cli.BoolFlag{
+			Name:        "profile",
+			Usage:       "print a table of the slowest tasks after the run",
+			Sources:     cli.EnvVars("PLUGIN_PROFILE"),
+			Destination: &settings.Profile,
+			Category:    category,
+		},
+		&cli.IntFlag{
+			Name:        "profile-limit",
+			Usage:       "number of tasks in the table of the slowest tasks",
+			Sources:     cli.EnvVars("PLUGIN_PROFILE_LIMIT"),
+			Value:       20, //nolint:mnd
+			Destination: &settings.ProfileLimit,
+			Category:    category,
+		},
+		&cli.StringFlag{
+			Name:        "profile-output",
+			Usage:       "path to write the timing profile of all tasks to as json",
+			Sources:     cli.EnvVars("PLUGIN_PROFILE_OUTPUT"),
+			Destination: &settings.ProfileOutput,
 			Category:    category,
 		},
 		&cli.StringSliceFlag{
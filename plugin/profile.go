@@ -0,0 +1,130 @@
+package plugin
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+const profileRound = 10 * time.Millisecond
+
+// taskProfile is the timing of a task across all hosts of a run.
+type taskProfile struct {
+	Run      string         `json:"run,omitempty"`
+	Play     string         `json:"play"`
+	Task     string         `json:"task"`
+	Handler  bool           `json:"handler,omitempty"`
+	Start    time.Time      `json:"start"`
+	Duration float64        `json:"duration"`
+	Hosts    []*hostProfile `json:"hosts"`
+}
+
+// hostProfile is the timing of a task on a host.
+type hostProfile struct {
+	Host     string  `json:"host"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+}
+
+// profileTasks returns the tasks of all runs ordered by duration, slowest first. The
+// hosts of a task are ordered the same way.
+func profileTasks(groups []runGroup) []*taskProfile {
+	tasks := make([]*taskProfile, 0)
+
+	for _, group := range groups {
+		for _, run := range group {
+			for _, play := range run.timeline.Plays {
+				for _, task := range play.Tasks {
+					profile := &taskProfile{
+						Run:      run.name,
+						Play:     play.Name,
+						Task:     task.Name,
+						Handler:  task.Handler,
+						Start:    task.Start,
+						Duration: task.End.Sub(task.Start).Seconds(),
+						Hosts:    make([]*hostProfile, 0, len(task.Hosts)),
+					}
+
+					for _, result := range task.Hosts {
+						profile.Hosts = append(profile.Hosts, &hostProfile{
+							Host:     result.Host,
+							Status:   result.Status,
+							Duration: result.Duration(task).Seconds(),
+						})
+					}
+
+					slices.SortStableFunc(profile.Hosts, func(a, b *hostProfile) int {
+						return cmp.Compare(b.Duration, a.Duration)
+					})
+
+					tasks = append(tasks, profile)
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(tasks, func(a, b *taskProfile) int {
+		return cmp.Compare(b.Duration, a.Duration)
+	})
+
+	return tasks
+}
+
+// printProfile writes a table of the slowest tasks. A limit of zero or less prints all tasks.
+func printProfile(w io.Writer, tasks []*taskProfile, limit int) {
+	if limit > 0 && limit < len(tasks) {
+		tasks = tasks[:limit]
+	}
+
+	named := slices.ContainsFunc(tasks, func(t *taskProfile) bool { return t.Run != "" })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprint(tw, "\nDURATION\tTASK\tPLAY\tSLOWEST HOST")
+
+	if named {
+		fmt.Fprint(tw, "\tRUN")
+	}
+
+	fmt.Fprintln(tw)
+
+	for _, task := range tasks {
+		slowest := "-"
+		if len(task.Hosts) > 0 {
+			slowest = fmt.Sprintf("%s (%s)", task.Hosts[0].Host, seconds(task.Hosts[0].Duration))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s", seconds(task.Duration), task.Task, task.Play, slowest)
+
+		if named {
+			fmt.Fprintf(tw, "\t%s", task.Run)
+		}
+
+		fmt.Fprintln(tw)
+	}
+
+	tw.Flush()
+}
+
+// writeProfile writes the profile of all tasks as JSON file.
+func writeProfile(path string, tasks []*taskProfile) error {
+	data, err := json.MarshalIndent(map[string]any{"tasks": tasks}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644) //nolint:gosec,mnd
+}
+
+func seconds(s float64) time.Duration {
+	return (time.Duration(s * float64(time.Second))).Round(profileRound)
+}
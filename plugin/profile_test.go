@@ -0,0 +1,108 @@
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func profileGroups() []runGroup {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	task := func(name string, offset, duration time.Duration, hosts ...*ansible.HostResult) *ansible.TaskResult {
+		return &ansible.TaskResult{Name: name, Start: start.Add(offset), End: start.Add(offset + duration), Hosts: hosts}
+	}
+
+	host := func(name string, end time.Duration) *ansible.HostResult {
+		return &ansible.HostResult{Host: name, Status: ansible.StatusOk, End: start.Add(end)}
+	}
+
+	eu := &playRun{name: "eu"}
+	eu.timeline.Plays = []*ansible.PlayResult{{Name: "webservers", Tasks: []*ansible.TaskResult{
+		task("Gathering Facts", 0, 2*time.Second, host("web1", time.Second), host("web2", 2*time.Second)),
+		task("nginx : install", 2*time.Second, 30*time.Second, host("web1", 32*time.Second)),
+	}}}
+
+	us := &playRun{name: "us"}
+	us.timeline.Plays = []*ansible.PlayResult{{Name: "webservers", Tasks: []*ansible.TaskResult{
+		task("nginx : install", 0, 10*time.Second, host("web3", 10*time.Second)),
+	}}}
+
+	return []runGroup{{eu}, {us}}
+}
+
+func TestProfileTasks(t *testing.T) {
+	tasks := profileTasks(profileGroups())
+
+	got := make([]string, 0)
+	for _, task := range tasks {
+		got = append(got, task.Run+" "+task.Task)
+	}
+
+	assert.Equal(t, []string{"eu nginx : install", "us nginx : install", "eu Gathering Facts"}, got)
+	assert.InDelta(t, 30, tasks[0].Duration, 0.001)
+	assert.Equal(t, "web2", tasks[2].Hosts[0].Host)
+	assert.InDelta(t, 2, tasks[2].Hosts[0].Duration, 0.001)
+}
+
+func TestPrintProfile(t *testing.T) {
+	var b strings.Builder
+
+	printProfile(&b, profileTasks(profileGroups()), 2)
+
+	assert.Equal(t, `
+DURATION  TASK             PLAY        SLOWEST HOST  RUN
+30s       nginx : install  webservers  web1 (30s)    eu
+10s       nginx : install  webservers  web3 (10s)    us
+`, b.String())
+}
+
+func TestPrintProfileLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "limit", limit: 2, want: 2},
+		{name: "limit above tasks", limit: 20, want: 3},
+		{name: "zero limit", limit: 0, want: 3},
+		{name: "negative limit", limit: -1, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+
+			printProfile(&b, profileTasks(profileGroups()), tt.limit)
+
+			lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+			assert.Len(t, lines, tt.want+1)
+			assert.Equal(t, "30s       nginx : install  webservers  web1 (30s)    eu", lines[1])
+		})
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", "profile.json")
+
+	require.NoError(t, writeProfile(path, profileTasks(profileGroups())))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var got struct {
+		Tasks []*taskProfile `json:"tasks"`
+	}
+
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Len(t, got.Tasks, 3)
+	assert.Equal(t, "nginx : install", got.Tasks[0].Task)
+	assert.Equal(t, []*hostProfile{{Host: "web1", Status: ansible.StatusOk, Duration: 30}}, got.Tasks[0].Hosts)
+}
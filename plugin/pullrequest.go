@@ -0,0 +1,48 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrPullRequestApply = errors.New("refusing to apply changes for a pull request without check mode")
+
+// validatePullRequest enables the safe mode for pull request pipelines. Check and diff
+// mode are enforced and diffs are redacted, unless applying is explicitly allowed.
+func (p *Plugin) validatePullRequest() {
+	if !strings.HasPrefix(p.Settings.metadata.Event, "pull_request") {
+		return
+	}
+
+	if p.Settings.PullRequestApply {
+		log.Warn().Msg("pull request safe mode disabled, changes will be applied")
+
+		return
+	}
+
+	log.Info().Msg("pull request safe mode enabled, enforce check and diff mode")
+
+	p.Settings.safeMode = true
+	p.Settings.Ansible.Check = true
+	p.Settings.Ansible.Diff = true
+}
+
+// checkSafeMode ensures that none of the runs applies changes in safe mode.
+func (p *Plugin) checkSafeMode(groups []runGroup) error {
+	if !p.Settings.safeMode {
+		return nil
+	}
+
+	for _, group := range groups {
+		for _, run := range group {
+			if !run.ansible.Check {
+				return fmt.Errorf("%w: %s", ErrPullRequestApply, run.name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePullRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		apply    bool
+		wantSafe bool
+	}{
+		{name: "push", event: "push"},
+		{name: "pull request", event: "pull_request", wantSafe: true},
+		{name: "pull request with apply", event: "pull_request", apply: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{
+				PullRequestApply: tt.apply,
+				metadata:         &metadata{Event: tt.event},
+			}}
+
+			p.validatePullRequest()
+
+			assert.Equal(t, tt.wantSafe, p.Settings.safeMode)
+			assert.Equal(t, tt.wantSafe, p.Settings.Ansible.Check)
+			assert.Equal(t, tt.wantSafe, p.Settings.Ansible.Diff)
+			assert.NoError(t, p.checkSafeMode(p.planRuns()))
+
+			p.Settings.PerPlaybook = true
+			p.Settings.Ansible.Playbooks = []string{"site.yml"}
+			p.Settings.PlaybookOverrides = playbookOverrides{{Pattern: "site.yml", Check: new(bool)}}
+
+			if tt.wantSafe {
+				assert.ErrorIs(t, p.checkSafeMode(p.planRuns()), ErrPullRequestApply)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package plugin
+
+import (
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	phasePip      = "pip"
+	phaseGalaxy   = "galaxy"
+	phaseLock     = "lock"
+	phaseApproval = "approval"
+	phasePlay     = "play"
+)
+
+// phase is a timed step of the run.
+type phase struct {
+	name  string
+	start time.Time
+	end   time.Time
+	err   error
+}
+
+// runPhase runs fn and records its duration as phase of the run.
+func (p *Plugin) runPhase(name string, fn func() error) error {
+	ph := &phase{name: name, start: time.Now()}
+
+	ph.err = fn()
+	ph.end = time.Now()
+
+	p.Settings.phases = append(p.Settings.phases, ph)
+
+	return ph.err
+}
+
+// report publishes the outcome of the run to the task profile and the configured
+// notifications and telemetry backends. Failures are logged but do not fail the run.
+func (p *Plugin) report(start time.Time, runErr error, groups []runGroup) {
+	end := time.Now()
+
+	if tasks := profileTasks(groups); len(tasks) > 0 {
+		if p.Settings.Profile {
+			printProfile(os.Stdout, tasks, p.Settings.ProfileLimit)
+		}
+
+		if p.Settings.ProfileOutput != "" {
+			if err := writeProfile(p.Settings.ProfileOutput, tasks); err != nil {
+				log.Warn().Err(err).Msg("failed to write task profile")
+			}
+		}
+	}
+
+	if len(p.Settings.Notifications) > 0 {
+		status := NotifySuccess
+		if runErr != nil {
+			status = NotifyFailure
+		}
+
+		p.notify(status, runErr, end.Sub(start), groups)
+	}
+
+	if p.Settings.OTelEndpoint != "" {
+		if err := p.exportTrace(start, end, runErr, groups); err != nil {
+			log.Warn().Err(err).Msg("failed to export trace")
+		}
+	}
+
+	if p.Settings.MetricsURL != "" {
+		if err := p.pushMetrics(start, end, runErr, groups); err != nil {
+			log.Warn().Err(err).Msg("failed to push metrics")
+		}
+	}
+}
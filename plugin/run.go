@@ -0,0 +1,292 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrPlayFailed = errors.New("playbook run failed")
+
+// PlaybookOverride holds settings that are overridden for the playbooks matching the
+// pattern in per-playbook mode.
+type PlaybookOverride struct {
+	Pattern  string `yaml:"-"`
+	Tags     string `yaml:"tags"`
+	SkipTags string `yaml:"skip_tags"`
+	Limit    string `yaml:"limit"`
+	Check    *bool  `yaml:"check"`
+}
+
+// playbookOverrides is a list of playbook overrides given as map of patterns to overrides.
+// The declared order of the patterns is kept.
+type playbookOverrides []*PlaybookOverride
+
+func (o *playbookOverrides) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode {
+		// Let the decoder report the type error, or accept null.
+		return value.Decode(&map[string]*PlaybookOverride{})
+	}
+
+	overrides := make(playbookOverrides, 0, len(value.Content)/2) //nolint:mnd
+
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		override := &PlaybookOverride{Pattern: value.Content[i].Value}
+		if err := value.Content[i+1].Decode(override); err != nil {
+			return err
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	*o = overrides
+
+	return nil
+}
+
+// playRun is a single ansible-playbook invocation of the run plan.
+type playRun struct {
+	name     string
+	ansible  ansible.Ansible
+	err      error
+	skipped  bool
+	start    time.Time
+	duration time.Duration
+	output   *bytes.Buffer
+	recap    ansible.Recap
+	timeline ansible.Timeline
+}
+
+// runGroup is a sequence of playbook runs. Groups are executed in parallel, the runs
+// of a group in order.
+type runGroup []*playRun
+
+// planRuns returns the playbook runs. In matrix mode, the playbooks are run separately
+// for each inventory, in per-playbook mode, each playbook is run separately in the
+// declared order.
+func (p *Plugin) planRuns() []runGroup {
+	inventories := [][]string{p.Settings.Ansible.Inventories}
+
+	if p.Settings.InventoryMatrix {
+		inventories = make([][]string, 0, len(p.Settings.Ansible.Inventories))
+
+		for _, inventory := range p.Settings.Ansible.Inventories {
+			inventories = append(inventories, []string{inventory})
+		}
+	}
+
+	playbooks := [][]string{p.Settings.Ansible.Playbooks}
+
+	if p.Settings.PerPlaybook {
+		playbooks = make([][]string, 0, len(p.Settings.Ansible.Playbooks))
+
+		for _, playbook := range p.Settings.Ansible.Playbooks {
+			playbooks = append(playbooks, []string{playbook})
+		}
+	}
+
+	groups := make([]runGroup, 0, len(inventories))
+
+	for _, inventory := range inventories {
+		group := make(runGroup, 0, len(playbooks))
+
+		for _, playbook := range playbooks {
+			a := p.Settings.Ansible
+			a.Inventories = inventory
+			a.Playbooks = playbook
+
+			name := make([]string, 0)
+
+			if p.Settings.InventoryMatrix {
+				name = append(name, inventory[0])
+			}
+
+			if p.Settings.PerPlaybook {
+				name = append(name, playbook[0])
+				p.applyPlaybookOverrides(&a, playbook[0])
+			}
+
+			group = append(group, &playRun{name: strings.Join(name, " "), ansible: a})
+		}
+
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// applyPlaybookOverrides applies the overrides with a pattern matching the playbook in
+// the declared order of the patterns, later overrides take precedence.
+func (p *Plugin) applyPlaybookOverrides(a *ansible.Ansible, playbook string) {
+	for _, override := range p.Settings.PlaybookOverrides {
+		if ok, _ := filepath.Match(override.Pattern, playbook); !ok {
+			continue
+		}
+
+		if override.Tags != "" {
+			a.Tags = override.Tags
+		}
+
+		if override.SkipTags != "" {
+			a.SkipTags = override.SkipTags
+		}
+
+		if override.Limit != "" {
+			a.Limit = override.Limit
+		}
+
+		if override.Check != nil {
+			a.Check = *override.Check
+		}
+	}
+}
+
+// runPlays executes the playbook runs with the configured parallelism and reports the
+// aggregated results.
+func (p *Plugin) runPlays(groups []runGroup) error {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	sem := make(chan struct{}, max(p.Settings.InventoryMatrixParallel, 1))
+	runs := make([]*playRun, 0)
+
+	for _, group := range groups {
+		runs = append(runs, group...)
+		sem <- struct{}{}
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			p.runGroup(group, &mu)
+		})
+	}
+
+	wg.Wait()
+
+	if len(runs) == 1 {
+		return runs[0].err
+	}
+
+	printSummary(os.Stdout, runs)
+
+	failed := 0
+
+	for _, run := range runs {
+		if run.err != nil {
+			log.Error().Err(run.err).Str("run", run.name).Msg("playbook run failed")
+
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d runs", ErrPlayFailed, failed, len(runs))
+	}
+
+	return nil
+}
+
+// runGroup executes the runs of a group in order. After a failed run, the remaining
+// runs are skipped unless continue-on-error is enabled. All remaining runs are skipped
+// if the deployment lock was lost.
+func (p *Plugin) runGroup(group runGroup, mu *sync.Mutex) {
+	failed := false
+
+	for _, run := range group {
+		if failed && !p.Settings.ContinueOnError || p.lockErr() != nil {
+			run.skipped = true
+
+			continue
+		}
+
+		run.start = time.Now()
+		run.err = p.runPlay(run, mu)
+		run.duration = time.Since(run.start)
+
+		failed = failed || run.err != nil
+	}
+}
+
+// runPlay executes a single playbook run. The output of named runs is prefixed with
+// the run name to keep parallel runs distinguishable.
+func (p *Plugin) runPlay(run *playRun, mu *sync.Mutex) error {
+	cmd := run.ansible.Play()
+	cmd.Env = p.environ()
+
+	if run.name != "" {
+		stdout := newPrefixWriter(os.Stdout, run.name, mu)
+		defer stdout.Flush()
+
+		stderr := newPrefixWriter(os.Stderr, run.name, mu)
+		defer stderr.Flush()
+
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	}
+
+	if p.Settings.safeMode {
+		w := ansible.NewDiffRedactWriter(cmd.Stdout)
+		defer w.Flush()
+
+		cmd.Stdout = w
+	}
+
+	recap := ansible.NewRecapWriter(&run.recap)
+	defer recap.Flush()
+
+	timeline := ansible.NewTimelineWriter(&run.timeline)
+	defer run.timeline.Finish()
+	defer timeline.Flush()
+
+	writers := []io.Writer{cmd.Stdout, recap, timeline}
+	if run.output != nil {
+		writers = append(writers, run.output)
+	}
+
+	cmd.Stdout = io.MultiWriter(writers...)
+
+	return cmd.Run()
+}
+
+func newPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *ansible.LineWriter {
+	return ansible.NewLineWriter(func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fmt.Fprintf(w, "[%s] %s", prefix, line)
+	})
+}
+
+// printSummary writes a table with the result of each run.
+func printSummary(w io.Writer, runs []*playRun) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(tw, "\nRUN\tSTATUS\tDURATION")
+
+	for _, run := range runs {
+		status := "ok"
+
+		switch {
+		case run.skipped:
+			status = "skipped"
+		case run.err != nil:
+			status = "failed"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", run.name, status, run.duration.Round(time.Second))
+	}
+
+	tw.Flush()
+}
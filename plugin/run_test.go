@@ -0,0 +1,249 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+// fakeAnsibleBin creates an ansible-playbook script that fails for inventories named "fail".
+func fakeAnsibleBin(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\necho \"$@\"\ncase \"$*\" in *--inventory\\ fail*) exit 1;; esac\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ansible-playbook"), []byte(script), 0o700)) //nolint:gosec
+
+	return dir
+}
+
+func TestPlanRuns(t *testing.T) {
+	check := true
+
+	tests := []struct {
+		name     string
+		settings *Settings
+		want     [][]string
+	}{
+		{
+			name:     "single run",
+			settings: &Settings{},
+			want:     [][]string{{""}},
+		},
+		{
+			name:     "inventory matrix",
+			settings: &Settings{InventoryMatrix: true},
+			want:     [][]string{{"eu.yml"}, {"us.yml"}},
+		},
+		{
+			name:     "per playbook",
+			settings: &Settings{PerPlaybook: true},
+			want:     [][]string{{"migrate.yml", "site.yml"}},
+		},
+		{
+			name:     "inventory matrix per playbook",
+			settings: &Settings{InventoryMatrix: true, PerPlaybook: true},
+			want:     [][]string{{"eu.yml migrate.yml", "eu.yml site.yml"}, {"us.yml migrate.yml", "us.yml site.yml"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.settings.Ansible = ansible.Ansible{
+				Inventories: []string{"eu.yml", "us.yml"},
+				Playbooks:   []string{"migrate.yml", "site.yml"},
+				Tags:        "deploy",
+			}
+			tt.settings.PlaybookOverrides = playbookOverrides{
+				{Pattern: "migrate.yml", Tags: "migrate", Limit: "db", Check: &check},
+			}
+
+			p := &Plugin{Settings: tt.settings}
+			groups := p.planRuns()
+
+			got := make([][]string, 0, len(groups))
+
+			for _, group := range groups {
+				names := make([]string, 0, len(group))
+
+				for _, run := range group {
+					names = append(names, run.name)
+
+					if strings.HasSuffix(run.name, "migrate.yml") {
+						assert.Equal(t, "migrate", run.ansible.Tags)
+						assert.Equal(t, "db", run.ansible.Limit)
+						assert.True(t, run.ansible.Check)
+					} else {
+						assert.Equal(t, "deploy", run.ansible.Tags)
+						assert.Empty(t, run.ansible.Limit)
+						assert.False(t, run.ansible.Check)
+					}
+				}
+
+				got = append(got, names)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyPlaybookOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+		wantTags  string
+		wantLimit string
+		wantErr   bool
+	}{
+		{
+			name:      "specific pattern last",
+			overrides: `{"*.yml": {"tags": "all", "limit": "web"}, "migrate.yml": {"tags": "migrate"}}`,
+			wantTags:  "migrate",
+			wantLimit: "web",
+		},
+		{
+			name:      "specific pattern first",
+			overrides: "migrate.yml:\n  tags: migrate\n'*.yml':\n  tags: all\n  limit: web\n",
+			wantTags:  "all",
+			wantLimit: "web",
+		},
+		{
+			name:      "list",
+			overrides: `[{"tags": "all"}]`,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &Settings{}
+
+			err := newYAMLValue(&settings.PlaybookOverrides).Set(tt.overrides)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			a := ansible.Ansible{Tags: "deploy"}
+			(&Plugin{Settings: settings}).applyPlaybookOverrides(&a, "migrate.yml")
+
+			assert.Equal(t, tt.wantTags, a.Tags)
+			assert.Equal(t, tt.wantLimit, a.Limit)
+		})
+	}
+}
+
+func TestRunPlays(t *testing.T) {
+	binDir := fakeAnsibleBin(t)
+
+	tests := []struct {
+		name            string
+		inventories     []string
+		parallel        int
+		perPlaybook     bool
+		continueOnError bool
+		wantSkipped     []bool
+		wantErr         error
+	}{
+		{name: "sequential", inventories: []string{"eu", "us"}},
+		{name: "parallel", inventories: []string{"eu", "us", "ap"}, parallel: 2},
+		{name: "failed run", inventories: []string{"eu", "fail", "us"}, parallel: 2, wantErr: ErrPlayFailed},
+		{
+			name:        "skip after failed playbook",
+			inventories: []string{"fail"},
+			perPlaybook: true,
+			wantSkipped: []bool{false, true},
+			wantErr:     ErrPlayFailed,
+		},
+		{
+			name:            "continue on error",
+			inventories:     []string{"fail"},
+			perPlaybook:     true,
+			continueOnError: true,
+			wantSkipped:     []bool{false, false},
+			wantErr:         ErrPlayFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{
+				InventoryMatrix:         true,
+				InventoryMatrixParallel: tt.parallel,
+				PerPlaybook:             tt.perPlaybook,
+				ContinueOnError:         tt.continueOnError,
+				Ansible: ansible.Ansible{
+					BinDir:      binDir,
+					Inventories: tt.inventories,
+					Playbooks:   []string{"migrate.yml", "site.yml"},
+				},
+			}}
+
+			groups := p.planRuns()
+
+			err := p.runPlays(groups)
+
+			if tt.wantSkipped != nil {
+				skipped := make([]bool, 0)
+				for _, run := range groups[0] {
+					skipped = append(skipped, run.skipped)
+				}
+
+				assert.Equal(t, tt.wantSkipped, skipped)
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			for _, group := range groups {
+				for _, run := range group {
+					assert.NoError(t, run.err)
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var (
+		b  strings.Builder
+		mu sync.Mutex
+	)
+
+	w := newPrefixWriter(&b, "eu.yml", &mu)
+	_, _ = w.Write([]byte("PLAY [all] ***\nok: [web1]\n"))
+	w.Flush()
+
+	assert.Equal(t, "[eu.yml] PLAY [all] ***\n[eu.yml] ok: [web1]\n", b.String())
+}
+
+func TestPrintSummary(t *testing.T) {
+	var b strings.Builder
+
+	printSummary(&b, []*playRun{
+		{name: "eu.yml", duration: 61 * time.Second},
+		{name: "us-east.yml", duration: 2 * time.Second, err: errors.New("exit status 2")},
+		{name: "us-west.yml", skipped: true},
+	})
+
+	want := "\nRUN          STATUS   DURATION\neu.yml       ok       1m1s\n" +
+		"us-east.yml  failed   2s\nus-west.yml  skipped  0s\n"
+	assert.Equal(t, want, b.String())
+}
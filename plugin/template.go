@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// Settings templates use square brackets to leave Jinja expressions untouched.
+const (
+	settingsDelimLeft  = "[["
+	settingsDelimRight = "]]"
+)
+
+// ErrTemplateDelimiters is returned for settings templates written with the default delimiters.
+var ErrTemplateDelimiters = errors.New("settings templates use [[ ]] delimiters")
+
+// legacyTemplateRe matches template field references with the default delimiters, e.g. `{{ .Branch }}`.
+// Jinja expressions never start with a dot, so they are not matched.
+var legacyTemplateRe = regexp.MustCompile(`\{\{-?\s*\.`) //nolint:gochecknoglobals
+
+// templateData is the context settings templates are rendered against.
+type templateData struct {
+	*metadata
+	Environment string
+	Env         map[string]string
+}
+
+// renderSettings renders Go templates in the inventories, limit, tags and extra vars settings.
+// The environment is filtered like the environment of the ansible processes.
+func (p *Plugin) renderSettings() error {
+	var err error
+
+	data := &templateData{
+		metadata:    p.Settings.metadata,
+		Environment: p.Settings.environment,
+		Env:         make(map[string]string),
+	}
+
+	for _, item := range p.inheritedEnv(os.Environ()) {
+		name, value, _ := strings.Cut(item, "=")
+		data.Env[name] = value
+	}
+
+	for i, inventory := range p.Settings.Ansible.Inventories {
+		if p.Settings.Ansible.Inventories[i], err = renderSettingTemplate("inventory", inventory, data); err != nil {
+			return err
+		}
+	}
+
+	if p.Settings.Ansible.Limit, err = renderSettingTemplate("limit", p.Settings.Ansible.Limit, data); err != nil {
+		return err
+	}
+
+	if p.Settings.Ansible.Tags, err = renderSettingTemplate("tags", p.Settings.Ansible.Tags, data); err != nil {
+		return err
+	}
+
+	for i, v := range p.Settings.Ansible.ExtraVars {
+		if p.Settings.Ansible.ExtraVars[i], err = renderSettingTemplate("extra-vars", v, data); err != nil {
+			return err
+		}
+	}
+
+	for key, value := range p.Settings.ExtraVars {
+		if p.Settings.ExtraVars[key], err = renderValue("extra-vars", value, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderValue renders templates in the string values of structured settings.
+func renderValue(name string, value any, data *templateData) (any, error) {
+	var err error
+
+	switch v := value.(type) {
+	case string:
+		return renderSettingTemplate(name, v, data)
+	case []any:
+		for i, item := range v {
+			if v[i], err = renderValue(name, item, data); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		for key, item := range v {
+			if v[key], err = renderValue(name, item, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return value, nil
+}
+
+// renderSettingTemplate renders a settings template with square bracket delimiters. References
+// written with the default delimiters would be passed to ansible unrendered and are rejected.
+func renderSettingTemplate(name, text string, data *templateData) (string, error) {
+	if legacyTemplateRe.MatchString(text) {
+		return "", fmt.Errorf("%w: replace `{{ .` with `[[ .` in %s template %q", ErrTemplateDelimiters, name, text)
+	}
+
+	return executeTemplate(name, text, settingsDelimLeft, settingsDelimRight, data)
+}
+
+// renderTemplate renders a template with the default delimiters.
+func renderTemplate(name, text string, data any) (string, error) {
+	return executeTemplate(name, text, "{{", "}}", data)
+}
+
+func executeTemplate(name, text, left, right string, data any) (string, error) {
+	if !strings.Contains(text, left) {
+		return text, nil
+	}
+
+	tmpl, err := template.New(name).Delims(left, right).Option("missingkey=error").Funcs(sprig.TxtFuncMap()).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s template: %w", name, err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,107 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestRenderSettings(t *testing.T) {
+	t.Setenv("DEPLOY_REGION", "eu")
+	t.Setenv("PLUGIN_VAULT_PASSWORD", "secret")
+	t.Setenv("DEPLOY_TOKEN", "secret")
+
+	p := &Plugin{Settings: &Settings{
+		EnvDeny: []string{"DEPLOY_TOKEN"},
+		Ansible: ansible.Ansible{
+			Inventories: []string{"inventories/[[ .Branch ]].yml", "inventories/common.yml"},
+			Limit:       "[[ .Env.DEPLOY_REGION ]]-*",
+			Tags:        "[[ .Tag | default \"latest\" ]]",
+			ExtraVars: []string{
+				"release=[[ .Commit | trunc 7 ]]",
+				"msg=plain",
+				"app_dir={{ base_dir }}/app",
+				`{"msg": "{{ inventory_hostname }} on [[ .Branch ]]"}`,
+			},
+		},
+		ExtraVars: map[string]any{
+			"hosts":   []any{"[[ .Env.DEPLOY_REGION ]]1", 2},
+			"release": map[string]any{"branch": "[[ .Branch | upper ]]"},
+			"app_dir": "{{ base_dir }}/app",
+		},
+		metadata: &metadata{Branch: "staging", Commit: "a1b2c3d4e5f6"},
+	}}
+
+	require.NoError(t, p.renderSettings())
+	assert.Equal(t, []string{"inventories/staging.yml", "inventories/common.yml"}, p.Settings.Ansible.Inventories)
+	assert.Equal(t, "eu-*", p.Settings.Ansible.Limit)
+	assert.Equal(t, "latest", p.Settings.Ansible.Tags)
+	assert.Equal(t, []string{
+		"release=a1b2c3d",
+		"msg=plain",
+		"app_dir={{ base_dir }}/app",
+		`{"msg": "{{ inventory_hostname }} on staging"}`,
+	}, p.Settings.Ansible.ExtraVars)
+	assert.Equal(t, map[string]any{
+		"hosts":   []any{"eu1", 2},
+		"release": map[string]any{"branch": "STAGING"},
+		"app_dir": "{{ base_dir }}/app",
+	}, p.Settings.ExtraVars)
+}
+
+func TestRenderSettingsSecretEnv(t *testing.T) {
+	t.Setenv("PLUGIN_VAULT_PASSWORD", "secret")
+	t.Setenv("DEPLOY_TOKEN", "secret")
+
+	for _, limit := range []string{"[[ .Env.PLUGIN_VAULT_PASSWORD ]]", "[[ .Env.DEPLOY_TOKEN ]]"} {
+		p := &Plugin{Settings: &Settings{
+			EnvDeny:  []string{"DEPLOY_TOKEN"},
+			Ansible:  ansible.Ansible{Limit: limit},
+			metadata: &metadata{},
+		}}
+
+		assert.Error(t, p.renderSettings(), limit)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	data := &templateData{metadata: &metadata{Branch: "main"}, Env: map[string]string{}}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain text", text: "site.yml", want: "site.yml"},
+		{name: "metadata", text: "[[ .Branch ]].yml", want: "main.yml"},
+		{name: "jinja", text: "{{ inventory_hostname }}", want: "{{ inventory_hostname }}"},
+		{name: "missing env", text: "[[ .Env.MISSING ]]", wantErr: true},
+		{name: "invalid template", text: "[[ .Branch", wantErr: true},
+		{name: "default delimiters", text: "{{ .Branch }}.yml", wantErr: true},
+		{name: "default delimiters trimmed", text: "{{- .Branch }}.yml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderSettingTemplate("test", tt.text, data)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateTemplateDelimiters(t *testing.T) {
+	p := &Plugin{Settings: &Settings{Templates: true}}
+	p.Settings.Ansible.Inventories = []string{"inventories/{{ .Branch }}.yml"}
+
+	assert.ErrorIs(t, p.Validate(), ErrTemplateDelimiters)
+}
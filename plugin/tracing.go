@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+	"github.com/thegeeklab/wp-ansible/tracing"
+)
+
+const exportTimeout = 30 * time.Second
+
+// exportTrace sends a trace of the run to the OpenTelemetry collector. The run is the
+// root span with a child span per phase. The play phase contains the playbook runs
+// with the plays, tasks and host results parsed from the output.
+func (p *Plugin) exportTrace(start, end time.Time, runErr error, groups []runGroup) error {
+	tracer, err := tracing.NewTracer()
+	if err != nil {
+		return err
+	}
+
+	root := tracer.Start(nil, p.Settings.OTelServiceName, start, p.traceAttributes()...)
+	root.Finish(end, runErr)
+
+	for _, ph := range p.Settings.phases {
+		span := tracer.Start(root, ph.name, ph.start)
+		span.Finish(ph.end, ph.err)
+
+		if ph.name == phasePlay {
+			traceRuns(tracer, span, groups)
+		}
+	}
+
+	exporter := &tracing.Exporter{
+		Endpoint:    p.Settings.OTelEndpoint,
+		Headers:     p.Settings.OTelHeaders,
+		ServiceName: p.Settings.OTelServiceName,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	if err := exporter.Export(ctx, tracer.Spans()); err != nil {
+		return err
+	}
+
+	log.Info().Str("trace_id", tracer.TraceID()).Msg("trace exported")
+
+	return nil
+}
+
+func (p *Plugin) traceAttributes() []tracing.Attribute {
+	attrs := []tracing.Attribute{
+		tracing.String("ansible.inventories", strings.Join(p.Settings.Ansible.Inventories, ",")),
+		tracing.String("ansible.playbooks", strings.Join(p.Settings.Ansible.Playbooks, ",")),
+		tracing.Bool("ansible.check", p.Settings.Ansible.Check),
+	}
+
+	if p.Settings.environment != "" {
+		attrs = append(attrs, tracing.String("ansible.environment", p.Settings.environment))
+	}
+
+	if m := p.Settings.metadata; m != nil {
+		attrs = append(attrs,
+			tracing.String("ci.commit", m.Commit),
+			tracing.String("ci.branch", m.Branch),
+			tracing.String("ci.tag", m.Tag),
+			tracing.String("ci.author", m.Author),
+			tracing.String("ci.pipeline_url", m.PipelineURL),
+			tracing.String("ci.event", m.Event),
+		)
+	}
+
+	return attrs
+}
+
+// traceRuns adds a span for each playbook run with nested spans for its plays, tasks
+// and host results.
+func traceRuns(tracer *tracing.Tracer, parent *tracing.Span, groups []runGroup) {
+	for _, group := range groups {
+		for _, run := range group {
+			if run.skipped {
+				continue
+			}
+
+			name := "ansible-playbook"
+			if run.name != "" {
+				name = fmt.Sprintf("%s %s", name, run.name)
+			}
+
+			span := tracer.Start(parent, name, run.start,
+				tracing.String("ansible.inventories", strings.Join(run.ansible.Inventories, ",")),
+				tracing.String("ansible.playbooks", strings.Join(run.ansible.Playbooks, ",")),
+			)
+			span.Finish(run.start.Add(run.duration), run.err)
+
+			tracePlays(tracer, span, &run.timeline)
+		}
+	}
+}
+
+func tracePlays(tracer *tracing.Tracer, parent *tracing.Span, timeline *ansible.Timeline) {
+	for _, play := range timeline.Plays {
+		playSpan := tracer.Start(parent, play.Name, play.Start, tracing.String("ansible.play", play.Name))
+		playSpan.Finish(play.End, nil)
+
+		for _, task := range play.Tasks {
+			taskSpan := tracer.Start(playSpan, task.Name, task.Start,
+				tracing.String("ansible.task", task.Name),
+				tracing.Bool("ansible.handler", task.Handler),
+				tracing.Int("ansible.hosts", len(task.Hosts)),
+			)
+			taskSpan.Finish(task.End, nil)
+
+			for _, result := range task.Hosts {
+				hostSpan := tracer.Start(taskSpan, result.Host, task.Start,
+					tracing.String("ansible.host", result.Host),
+					tracing.String("ansible.status", result.Status),
+				)
+				hostSpan.Finish(result.End, nil)
+
+				if result.Status == ansible.StatusFailed || result.Status == ansible.StatusUnreachable {
+					hostSpan.Error = result.Status
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestExportTrace(t *testing.T) {
+	var body struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Status       *struct {
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	task := &ansible.TaskResult{
+		Name:  "nginx : install",
+		Start: start,
+		End:   start.Add(2 * time.Second),
+		Hosts: []*ansible.HostResult{
+			{Host: "web1", Status: ansible.StatusChanged, End: start.Add(time.Second)},
+			{Host: "web2", Status: ansible.StatusFailed, End: start.Add(2 * time.Second)},
+		},
+	}
+
+	p := &Plugin{Settings: &Settings{
+		OTelEndpoint:    server.URL,
+		OTelServiceName: "wp-ansible",
+		phases: []*phase{
+			{name: phasePip, start: start, end: start},
+			{name: phasePlay, start: start, end: start.Add(3 * time.Second), err: ErrPlayFailed},
+		},
+	}}
+
+	run := &playRun{start: start, duration: 3 * time.Second, err: ErrPlayFailed}
+	run.timeline.Plays = []*ansible.PlayResult{
+		{Name: "webservers", Start: start, End: start.Add(2 * time.Second), Tasks: []*ansible.TaskResult{task}},
+	}
+
+	groups := []runGroup{{run, {skipped: true}}}
+
+	require.NoError(t, p.exportTrace(start, start.Add(4*time.Second), errors.New("exit status 2"), groups))
+
+	require.Len(t, body.ResourceSpans, 1)
+	require.Len(t, body.ResourceSpans[0].ScopeSpans, 1)
+
+	names := make(map[string]string)
+	parents := make(map[string]string)
+	failed := make([]string, 0)
+
+	for _, span := range body.ResourceSpans[0].ScopeSpans[0].Spans {
+		names[span.SpanID] = span.Name
+		parents[span.Name] = span.ParentSpanID
+
+		if span.Status != nil {
+			failed = append(failed, span.Name)
+		}
+	}
+
+	parentOf := func(name string) string {
+		return names[parents[name]]
+	}
+
+	assert.Len(t, names, 8)
+	assert.Empty(t, parentOf("wp-ansible"))
+	assert.Equal(t, "wp-ansible", parentOf(phasePip))
+	assert.Equal(t, "wp-ansible", parentOf(phasePlay))
+	assert.Equal(t, phasePlay, parentOf("ansible-playbook"))
+	assert.Equal(t, "ansible-playbook", parentOf("webservers"))
+	assert.Equal(t, "webservers", parentOf("nginx : install"))
+	assert.Equal(t, "nginx : install", parentOf("web1"))
+	assert.Equal(t, "nginx : install", parentOf("web2"))
+	assert.Equal(t, []string{"wp-ansible", phasePlay, "ansible-playbook", "web2"}, failed)
+}
@@ -0,0 +1,116 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrExtraVarsInvalid = errors.New("invalid extra vars")
+
+// yamlValue is a flag value for structured settings. The value is decoded from YAML,
+// which includes the JSON encoding used to pass maps and lists of settings to plugins.
+type yamlValue[T any] struct {
+	dest *T
+	raw  string
+}
+
+func newYAMLValue[T any](dest *T) *yamlValue[T] {
+	return &yamlValue[T]{dest: dest}
+}
+
+func (v *yamlValue[T]) Set(s string) error {
+	v.raw = s
+
+	return yaml.Unmarshal([]byte(s), v.dest)
+}
+
+func (v *yamlValue[T]) String() string {
+	return v.raw
+}
+
+func (v *yamlValue[T]) destination() any {
+	return v.dest
+}
+
+func (v *yamlValue[T]) Get() any {
+	if v.dest == nil {
+		return nil
+	}
+
+	return *v.dest
+}
+
+// extraVarsValue is a flag value for extra vars. Plain values are split by comma into `key=value`
+// pairs and `@file` references, YAML or JSON objects are merged into the structured extra vars.
+type extraVarsValue struct {
+	vars *[]string
+	data *map[string]any
+	raw  []string
+}
+
+func newExtraVarsValue(vars *[]string, data *map[string]any) *extraVarsValue {
+	return &extraVarsValue{vars: vars, data: data}
+}
+
+func (v *extraVarsValue) Set(s string) error {
+	v.raw = append(v.raw, s)
+	trimmed := strings.TrimSpace(s)
+
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		data := make(map[string]any)
+		if err := yaml.Unmarshal([]byte(trimmed), &data); err != nil {
+			return fmt.Errorf("%w: %w", ErrExtraVarsInvalid, err)
+		}
+
+		v.merge(data)
+	case strings.HasPrefix(trimmed, "["):
+		items := make([]any, 0)
+		if err := yaml.Unmarshal([]byte(trimmed), &items); err != nil {
+			return fmt.Errorf("%w: %w", ErrExtraVarsInvalid, err)
+		}
+
+		for _, item := range items {
+			switch value := item.(type) {
+			case map[string]any:
+				v.merge(value)
+			case string:
+				*v.vars = append(*v.vars, value)
+			default:
+				return fmt.Errorf("%w: unexpected item %v", ErrExtraVarsInvalid, item)
+			}
+		}
+	default:
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*v.vars = append(*v.vars, item)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *extraVarsValue) String() string {
+	return strings.Join(v.raw, ",")
+}
+
+func (v *extraVarsValue) Get() any {
+	if v.vars == nil {
+		return nil
+	}
+
+	return *v.vars
+}
+
+func (v *extraVarsValue) merge(data map[string]any) {
+	if *v.data == nil {
+		*v.data = make(map[string]any)
+	}
+
+	maps.Copy(*v.data, data)
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLValue(t *testing.T) {
+	var dest map[string]map[string]any
+
+	v := newYAMLValue(&dest)
+
+	require.NoError(t, v.Set(`{"defaults": {"forks": 20, "gathering": "smart"}}`))
+	assert.Equal(t, map[string]map[string]any{"defaults": {"forks": 20, "gathering": "smart"}}, v.Get())
+	assert.Equal(t, `{"defaults": {"forks": 20, "gathering": "smart"}}`, v.String())
+
+	assert.Error(t, v.Set("defaults"))
+}
+
+func TestExtraVarsValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		wantVars []string
+		wantData map[string]any
+		wantErr  error
+	}{
+		{
+			name:     "key value pairs",
+			values:   []string{"var1=value1,var2=value2", "@vars.yml"},
+			wantVars: []string{"var1=value1", "var2=value2", "@vars.yml"},
+		},
+		{
+			name:     "json object",
+			values:   []string{`{"hosts": ["web1", "web2"], "feature": {"enabled": true}}`},
+			wantVars: []string{},
+			wantData: map[string]any{"hosts": []any{"web1", "web2"}, "feature": map[string]any{"enabled": true}},
+		},
+		{
+			name:     "mixed list",
+			values:   []string{`["var1=value1", {"replicas": 3}]`, `{"replicas": 5}`},
+			wantVars: []string{"var1=value1"},
+			wantData: map[string]any{"replicas": 5},
+		},
+		{
+			name:    "invalid object",
+			values:  []string{`{"hosts": [}`},
+			wantErr: ErrExtraVarsInvalid,
+		},
+		{
+			name:    "invalid list item",
+			values:  []string{`[1]`},
+			wantErr: ErrExtraVarsInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := []string{}
+
+			var data map[string]any
+
+			v := newExtraVarsValue(&vars, &data)
+
+			var err error
+			for _, value := range tt.values {
+				if err = v.Set(value); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVars, vars)
+			assert.Equal(t, tt.wantData, data)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/rs/zerolog/log"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+var ErrAnsibleVersionUnsupported = errors.New("ansible version not supported")
+
+// resolveAnsibleInstall checks whether the requested ansible-core version differs from
+// the installed one and has to be installed into a virtualenv.
+func (p *Plugin) resolveAnsibleInstall() error {
+	if p.Settings.AnsibleVersion == "" {
+		return nil
+	}
+
+	requested, err := ansible.ParseCoreVersion(p.Settings.AnsibleVersion)
+	if err != nil {
+		return fmt.Errorf("invalid ansible version: %w", err)
+	}
+
+	info, err := p.detectAnsibleVersion(io.Discard)
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to detect installed ansible version")
+	}
+
+	p.Settings.installAnsible = info == nil || !info.Core.Equal(requested)
+
+	return nil
+}
+
+// checkAnsibleVersion detects the version of the ansible binary used to run the playbooks
+// after the dependencies were installed, and checks it against the configured version
+// constraints and the features required by the settings.
+func (p *Plugin) checkAnsibleVersion() error {
+	info, err := p.detectAnsibleVersion(os.Stdout)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to detect ansible version")
+	}
+
+	p.Settings.Ansible.VersionInfo = info
+
+	if info != nil {
+		log.Info().
+			Str("ansible-core", info.Core.Original()).
+			Str("python", info.Python).
+			Str("config", info.ConfigFile).
+			Strs("collections", info.CollectionPaths).
+			Msg("ansible version")
+	}
+
+	constraints := make([]string, 0)
+
+	if p.Settings.MinAnsibleVersion != "" {
+		constraints = append(constraints, fmt.Sprintf(">= %s", p.Settings.MinAnsibleVersion))
+	}
+
+	if p.Settings.MaxAnsibleVersion != "" {
+		constraints = append(constraints, fmt.Sprintf("<= %s", p.Settings.MaxAnsibleVersion))
+	}
+
+	if len(constraints) > 0 {
+		if info == nil {
+			return fmt.Errorf("%w: unable to determine version", ErrAnsibleVersionUnsupported)
+		}
+
+		if err := checkVersionConstraints(info.Core, constraints...); err != nil {
+			return err
+		}
+	}
+
+	return p.Settings.Ansible.CheckFeatures()
+}
+
+// detectAnsibleVersion runs the version command and parses its output, which is also
+// written to w.
+func (p *Plugin) detectAnsibleVersion(w io.Writer) (*ansible.VersionInfo, error) {
+	var out bytes.Buffer
+
+	cmd := p.Settings.Ansible.Version()
+	cmd.Stdout = io.MultiWriter(w, &out)
+	cmd.Env = p.environ()
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return ansible.ParseVersion(out.Bytes())
+}
+
+func checkVersionConstraints(version *semver.Version, constraints ...string) error {
+	for _, c := range constraints {
+		constraint, err := semver.NewConstraint(c)
+		if err != nil {
+			return fmt.Errorf("invalid ansible version constraint: %w", err)
+		}
+
+		if !constraint.Check(version) {
+			return fmt.Errorf("%w: %s does not match %s", ErrAnsibleVersionUnsupported, version.Original(), c)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thegeeklab/wp-ansible/ansible"
+)
+
+func TestCheckVersionConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		constraints []string
+		wantErr     error
+	}{
+		{
+			name:        "within range",
+			version:     "2.17.1",
+			constraints: []string{">= 2.15", "<= 2.17"},
+		},
+		{
+			name:        "below minimum",
+			version:     "2.14.9",
+			constraints: []string{">= 2.15"},
+			wantErr:     ErrAnsibleVersionUnsupported,
+		},
+		{
+			name:        "above maximum",
+			version:     "2.18.0",
+			constraints: []string{"<= 2.17"},
+			wantErr:     ErrAnsibleVersionUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVersionConstraints(semver.MustParse(tt.version), tt.constraints...)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func fakeAnsibleVersionBin(t *testing.T, version string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\necho \"ansible [core %s]\"\n", version)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ansible"), []byte(script), 0o700)) //nolint:gosec
+
+	return dir
+}
+
+func TestResolveAnsibleInstall(t *testing.T) {
+	binDir := fakeAnsibleVersionBin(t, "2.17.1")
+
+	tests := []struct {
+		name    string
+		version string
+		want    bool
+	}{
+		{name: "installed version", version: "", want: false},
+		{name: "requested installed version", version: "2.17.1", want: false},
+		{name: "requested other version", version: "2.18.0", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: &Settings{AnsibleVersion: tt.version}}
+			p.Settings.Ansible.BinDir = binDir
+
+			require.NoError(t, p.resolveAnsibleInstall())
+			assert.Equal(t, tt.want, p.Settings.installAnsible)
+			assert.Nil(t, p.Settings.Ansible.VersionInfo)
+		})
+	}
+}
+
+func TestCheckAnsibleVersion(t *testing.T) {
+	// The image ships another version than the virtualenv running the playbooks.
+	venvBin := fakeAnsibleVersionBin(t, "2.13.9")
+
+	tests := []struct {
+		name     string
+		settings *Settings
+		wantErr  error
+	}{
+		{
+			name:     "without constraints",
+			settings: &Settings{},
+		},
+		{
+			name:     "below minimum",
+			settings: &Settings{MinAnsibleVersion: "2.15"},
+			wantErr:  ErrAnsibleVersionUnsupported,
+		},
+		{
+			name:     "unsupported feature",
+			settings: &Settings{Ansible: ansible.Ansible{GalaxyOffline: true}},
+			wantErr:  ansible.ErrAnsibleFeatureUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plugin{Settings: tt.settings}
+			p.Settings.Ansible.BinDir = venvBin
+
+			err := p.checkAnsibleVersion()
+			require.NotNil(t, p.Settings.Ansible.VersionInfo)
+			assert.Equal(t, "2.13.9", p.Settings.Ansible.VersionInfo.Core.Original())
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
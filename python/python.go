@@ -0,0 +1,149 @@
+package python
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	plugin_exec "github.com/thegeeklab/wp-plugin-go/v6/exec"
+)
+
+const (
+	pythonBin = "/usr/local/bin/python3"
+	pipBin    = "/usr/local/bin/pip"
+)
+
+type Python struct {
+	Requirements       string
+	Constraints        string
+	WheelDir           string
+	Virtualenv         string
+	SystemSitePackages bool
+}
+
+// VirtualenvCreate returns a command to create a virtual environment at the configured path.
+func (p *Python) VirtualenvCreate() *plugin_exec.Cmd {
+	args := []string{
+		"-m",
+		"venv",
+	}
+
+	if p.SystemSitePackages {
+		args = append(args, "--system-site-packages")
+	}
+
+	args = append(args, p.Virtualenv)
+
+	cmd := plugin_exec.Command(pythonBin, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// PipInstall returns a command to install Python packages from a requirements file.
+// The command will upgrade any existing packages and install the packages specified in the given requirements file.
+// If a wheel directory is configured, packages are installed from this directory only.
+func (p *Python) PipInstall() *plugin_exec.Cmd {
+	args := []string{
+		"install",
+		"--upgrade",
+		"--requirement",
+		p.Requirements,
+	}
+
+	if p.Constraints != "" {
+		args = append(args, "--constraint", p.Constraints)
+	}
+
+	if p.WheelDir != "" {
+		args = append(args, "--no-index", "--find-links", p.WheelDir)
+	}
+
+	cmd := plugin_exec.Command(p.pip(), args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// PipInstallPackage returns a command to install the given Python package.
+// If a wheel directory is configured, the package is installed from this directory only.
+func (p *Python) PipInstallPackage(pkg string) *plugin_exec.Cmd {
+	args := []string{
+		"install",
+		"--upgrade",
+		pkg,
+	}
+
+	if p.WheelDir != "" {
+		args = append(args, "--no-index", "--find-links", p.WheelDir)
+	}
+
+	cmd := plugin_exec.Command(p.pip(), args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// PipWheel returns a command to build wheels for all packages of a requirements file
+// and store them in the configured wheel directory.
+func (p *Python) PipWheel() *plugin_exec.Cmd {
+	args := []string{
+		"wheel",
+		"--wheel-dir",
+		p.WheelDir,
+		"--requirement",
+		p.Requirements,
+	}
+
+	if p.Constraints != "" {
+		args = append(args, "--constraint", p.Constraints)
+	}
+
+	cmd := plugin_exec.Command(p.pip(), args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// Interpreter returns the path of the Python interpreter, either of the
+// configured virtual environment or the system interpreter.
+func (p *Python) Interpreter() string {
+	if p.Virtualenv == "" {
+		return pythonBin
+	}
+
+	return filepath.Join(p.BinDir(), filepath.Base(pythonBin))
+}
+
+// BinDir returns the path of the scripts directory of the configured virtual environment.
+func (p *Python) BinDir() string {
+	if p.Virtualenv == "" {
+		return filepath.Dir(pythonBin)
+	}
+
+	return filepath.Join(p.Virtualenv, "bin")
+}
+
+// Environ returns the environment variables required to activate the configured virtual environment.
+func (p *Python) Environ() []string {
+	if p.Virtualenv == "" {
+		return []string{}
+	}
+
+	return []string{
+		fmt.Sprintf("VIRTUAL_ENV=%s", p.Virtualenv),
+		fmt.Sprintf("PATH=%s%c%s", p.BinDir(), os.PathListSeparator, os.Getenv("PATH")),
+	}
+}
+
+func (p *Python) pip() string {
+	if p.Virtualenv == "" {
+		return pipBin
+	}
+
+	return filepath.Join(p.BinDir(), filepath.Base(pipBin))
+}
@@ -0,0 +1,151 @@
+package python
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipInstall(t *testing.T) {
+	tests := []struct {
+		name   string
+		python *Python
+		want   []string
+	}{
+		{
+			name: "with valid requirements file",
+			python: &Python{
+				Requirements: "requirements.txt",
+			},
+			want: []string{pipBin, "install", "--upgrade", "--requirement", "requirements.txt"},
+		},
+		{
+			name: "with wheel directory",
+			python: &Python{
+				Requirements: "requirements.txt",
+				WheelDir:     "/cache/pip",
+			},
+			want: []string{
+				pipBin, "install", "--upgrade", "--requirement", "requirements.txt",
+				"--no-index", "--find-links", "/cache/pip",
+			},
+		},
+		{
+			name: "with virtualenv and constraints",
+			python: &Python{
+				Requirements: "requirements.txt",
+				Constraints:  "constraints.txt",
+				Virtualenv:   "/tmp/venv",
+			},
+			want: []string{
+				"/tmp/venv/bin/pip", "install", "--upgrade", "--requirement", "requirements.txt",
+				"--constraint", "constraints.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.python.PipInstall()
+			assert.Equal(t, tt.want, cmd.Args)
+		})
+	}
+}
+
+func TestPipInstallPackage(t *testing.T) {
+	tests := []struct {
+		name   string
+		python *Python
+		want   []string
+	}{
+		{
+			name: "with virtualenv",
+			python: &Python{
+				Virtualenv: "/tmp/venv",
+			},
+			want: []string{"/tmp/venv/bin/pip", "install", "--upgrade", "ansible-core==2.17.1"},
+		},
+		{
+			name: "with wheel directory",
+			python: &Python{
+				WheelDir: "/vendor/wheels",
+			},
+			want: []string{
+				pipBin, "install", "--upgrade", "ansible-core==2.17.1", "--no-index", "--find-links", "/vendor/wheels",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.python.PipInstallPackage("ansible-core==2.17.1")
+			assert.Equal(t, tt.want, cmd.Args)
+		})
+	}
+}
+
+func TestVirtualenvCreate(t *testing.T) {
+	tests := []struct {
+		name   string
+		python *Python
+		want   []string
+	}{
+		{
+			name: "with virtualenv",
+			python: &Python{
+				Virtualenv: "/tmp/venv",
+			},
+			want: []string{pythonBin, "-m", "venv", "/tmp/venv"},
+		},
+		{
+			name: "with system site packages",
+			python: &Python{
+				Virtualenv:         "/tmp/venv",
+				SystemSitePackages: true,
+			},
+			want: []string{pythonBin, "-m", "venv", "--system-site-packages", "/tmp/venv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.python.VirtualenvCreate()
+			assert.Equal(t, tt.want, cmd.Args)
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+
+	assert.Equal(t, []string{}, (&Python{}).Environ())
+	assert.Equal(t, []string{
+		"VIRTUAL_ENV=/tmp/venv",
+		"PATH=/tmp/venv/bin:/usr/bin",
+	}, (&Python{Virtualenv: "/tmp/venv"}).Environ())
+	assert.Equal(t, "/tmp/venv/bin/python3", (&Python{Virtualenv: "/tmp/venv"}).Interpreter())
+}
+
+func TestPipWheel(t *testing.T) {
+	tests := []struct {
+		name   string
+		python *Python
+		want   []string
+	}{
+		{
+			name: "with wheel directory",
+			python: &Python{
+				Requirements: "requirements.txt",
+				WheelDir:     "/cache/pip",
+			},
+			want: []string{pipBin, "wheel", "--wheel-dir", "/cache/pip", "--requirement", "requirements.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := tt.python.PipWheel()
+			assert.Equal(t, tt.want, cmd.Args)
+		})
+	}
+}
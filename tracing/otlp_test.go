@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	var (
+		path    string
+		headers http.Header
+		body    map[string]any
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		path, headers = r.URL.Path, r.Header
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	tracer, err := NewTracer()
+	require.NoError(t, err)
+
+	start := time.Unix(1700000000, 0)
+	root := tracer.Start(nil, "wp-ansible", start, String("ci.commit", "a1b2c3"))
+	child := tracer.Start(root, "play", start.Add(time.Second), Int("tasks", 3), Bool("check", true))
+	child.Finish(start.Add(2*time.Second), errors.New("exit status 2"))
+	root.Finish(start.Add(3*time.Second), nil)
+
+	exporter := &Exporter{
+		Endpoint:    server.URL + "/",
+		Headers:     map[string]string{"X-Token": "secret"},
+		ServiceName: "deploy",
+	}
+	require.NoError(t, exporter.Export(t.Context(), tracer.Spans()))
+
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "secret", headers.Get("X-Token"))
+
+	raw, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	want := `{"resourceSpans": [{
+		"resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "deploy"}}]},
+		"scopeSpans": [{"scope": {"name": "deploy"}, "spans": [
+			{
+				"traceId": "` + tracer.TraceID() + `", "spanId": "` + spanID(root) + `", "name": "wp-ansible", "kind": 1,
+				"startTimeUnixNano": "1700000000000000000", "endTimeUnixNano": "1700000003000000000",
+				"attributes": [{"key": "ci.commit", "value": {"stringValue": "a1b2c3"}}]
+			},
+			{
+				"traceId": "` + tracer.TraceID() + `", "spanId": "` + spanID(child) + `",
+				"parentSpanId": "` + spanID(root) + `", "name": "play", "kind": 1,
+				"startTimeUnixNano": "1700000001000000000", "endTimeUnixNano": "1700000002000000000",
+				"attributes": [
+					{"key": "tasks", "value": {"intValue": "3"}},
+					{"key": "check", "value": {"boolValue": true}}
+				],
+				"status": {"code": 2, "message": "exit status 2"}
+			}
+		]}]
+	}]}`
+
+	assert.JSONEq(t, want, string(raw))
+}
+
+func TestExportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := (&Exporter{Endpoint: server.URL + "/v1/traces"}).Export(t.Context(), nil)
+	assert.ErrorIs(t, err, ErrExportFailed)
+}
+
+func spanID(s *Span) string {
+	return hex.EncodeToString(s.SpanID[:])
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Span is a timed operation of a trace.
+type Span struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	ParentID   [8]byte
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Error      string
+}
+
+// Attribute is a key value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer records the spans of a single trace.
+type Tracer struct {
+	traceID [16]byte
+	mu      sync.Mutex
+	spans   []*Span
+}
+
+// String creates a string attribute.
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int creates an integer attribute.
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+// Bool creates a boolean attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// NewTracer creates a tracer with a random trace ID.
+func NewTracer() (*Tracer, error) {
+	t := &Tracer{}
+	if _, err := rand.Read(t.traceID[:]); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// TraceID returns the hex encoded ID of the trace.
+func (t *Tracer) TraceID() string {
+	return hex.EncodeToString(t.traceID[:])
+}
+
+// Start records a span. A span without parent is a root span.
+func (t *Tracer) Start(parent *Span, name string, start time.Time, attrs ...Attribute) *Span {
+	s := &Span{TraceID: t.traceID, Name: name, Start: start, Attributes: attrs}
+	_, _ = rand.Read(s.SpanID[:])
+
+	if parent != nil {
+		s.ParentID = parent.SpanID
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.spans = append(t.spans, s)
+
+	return s
+}
+
+// Spans returns the recorded spans.
+func (t *Tracer) Spans() []*Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*Span(nil), t.spans...)
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	s.Attributes = append(s.Attributes, attrs...)
+}
+
+// Finish sets the end time of the span and marks it as failed if err is not nil.
+func (s *Span) Finish(end time.Time, err error) {
+	s.End = end
+
+	if err != nil {
+		s.Error = err.Error()
+	}
+}